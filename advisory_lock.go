@@ -0,0 +1,130 @@
+package gomodel
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+// AdvisoryLockKey stable 64-bit key of model identity
+// Key is a hash of model table and primary key values
+func AdvisoryLockKey(model IModel) (int64, porterr.IError) {
+	meta := PrepareMetaModel(model)
+	if meta == nil {
+		return 0, porterr.New(porterr.PortErrorArgument, "Model must not be nil")
+	}
+	keys := meta.Fields.Keys()
+	if len(keys) == 0 {
+		return 0, porterr.New(porterr.PortErrorArgument, "Model "+meta.TableName+" has no primary key")
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(meta.TableName))
+	for i := range keys {
+		v := reflect.ValueOf(keys[i].Value)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Ptr {
+			return 0, porterr.New(porterr.PortErrorArgument, "Primary key "+keys[i].Column+" is empty")
+		}
+		_, _ = h.Write([]byte{0})
+		_, _ = fmt.Fprint(h, v.Interface())
+	}
+	return int64(h.Sum64()), nil
+}
+
+// advisoryTx run fn in transaction holding transaction-level advisory lock
+func advisoryTx(q godb.Queryer, model IModel, try bool, fn func(tx godb.Queryer) porterr.IError) (acquired bool, e porterr.IError) {
+	key, e := AdvisoryLockKey(model)
+	if e != nil {
+		return
+	}
+	e = InTx(q, func(tx godb.Queryer) porterr.IError {
+		if try {
+			if err := tx.QueryRow("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&acquired); err != nil {
+				return NewDatabaseError(err)
+			}
+			if !acquired {
+				return nil
+			}
+		} else {
+			if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", key); err != nil {
+				return NewDatabaseError(err)
+			}
+			acquired = true
+		}
+		return fn(tx)
+	}, TxOptions{})
+	return
+}
+
+// WithAdvisoryLock run fn in transaction holding transaction-level advisory lock of model
+// Lock is released on transaction end. Waits for lock held by another session
+// q - *godb.DBO, *Router or transaction. Nested transaction runs in SAVEPOINT
+func WithAdvisoryLock(q godb.Queryer, model IModel, fn func(tx godb.Queryer) porterr.IError) porterr.IError {
+	_, e := advisoryTx(q, model, false, fn)
+	return e
+}
+
+// TryAdvisoryLock run fn in transaction if transaction-level advisory lock of model is acquired without waiting
+// Returns false if lock is held by another session
+func TryAdvisoryLock(q godb.Queryer, model IModel, fn func(tx godb.Queryer) porterr.IError) (bool, porterr.IError) {
+	return advisoryTx(q, model, true, fn)
+}
+
+// advisorySession run fn holding session-level advisory lock on dedicated connection
+func advisorySession(ctx context.Context, q godb.Queryer, model IModel, try bool, fn func() porterr.IError) (acquired bool, e porterr.IError) {
+	key, e := AdvisoryLockKey(model)
+	if e != nil {
+		return
+	}
+	if router, ok := q.(*Router); ok {
+		q = router.Primary()
+	}
+	db, ok := q.(*godb.DBO)
+	if !ok {
+		return false, porterr.New(porterr.PortErrorArgument, "Queryer for session lock must be *godb.DBO or *Router")
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, NewDatabaseError(err)
+	}
+	defer func() { _ = conn.Close() }()
+	if try {
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+	} else {
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key)
+		acquired = err == nil
+	}
+	if err != nil {
+		return false, NewDatabaseError(err)
+	}
+	if !acquired {
+		return
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil && e == nil {
+			e = NewDatabaseError(err)
+		}
+	}()
+	e = fn()
+	return
+}
+
+// WithSessionAdvisoryLock run fn holding session-level advisory lock of model
+// Lock is taken on dedicated connection and released after fn. Use for long work outside of transaction
+// q - *godb.DBO or *Router
+func WithSessionAdvisoryLock(ctx context.Context, q godb.Queryer, model IModel, fn func() porterr.IError) porterr.IError {
+	_, e := advisorySession(ctx, q, model, false, fn)
+	return e
+}
+
+// TrySessionAdvisoryLock run fn if session-level advisory lock of model is acquired without waiting
+// Returns false if lock is held by another session
+func TrySessionAdvisoryLock(ctx context.Context, q godb.Queryer, model IModel, fn func() porterr.IError) (bool, porterr.IError) {
+	return advisorySession(ctx, q, model, true, fn)
+}
@@ -0,0 +1,40 @@
+package gomodel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dimonrus/porterr"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	id := 1
+	key, e := AdvisoryLockKey(&InsertModel1{Id: &id})
+	if e != nil {
+		t.Fatal(e)
+	}
+	same, _ := AdvisoryLockKey(&InsertModel1{Id: &id})
+	other, _ := AdvisoryLockKey(&UpdateModel1{Id: &id})
+	id2 := 2
+	next, _ := AdvisoryLockKey(&InsertModel1{Id: &id2})
+	if key != same || key == other || key == next {
+		t.Fatal("wrong advisory lock keys")
+	}
+	if _, e = AdvisoryLockKey(&InsertModel1{}); e == nil {
+		t.Fatal("empty primary key must be error")
+	}
+	if _, e = AdvisoryLockKey(&DeleteModel2{Id: &id}); e == nil {
+		t.Fatal("model without primary key must be error")
+	}
+}
+
+func TestWithSessionAdvisoryLock(t *testing.T) {
+	id := 1
+	e := WithSessionAdvisoryLock(context.Background(), &Tx{}, &InsertModel1{Id: &id}, func() porterr.IError {
+		t.Fatal("must not be called")
+		return nil
+	})
+	if e == nil {
+		t.Fatal("transaction must not be used for session lock")
+	}
+}
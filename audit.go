@@ -0,0 +1,226 @@
+package gomodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// AuditTableName default audit log table name
+const AuditTableName = "audit_log"
+
+// key for actor in context
+type auditActorKey struct{}
+
+// WithActor put actor of changes to context
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// ActorFromContext get actor of changes from context
+func ActorFromContext(ctx context.Context) (actor string, ok bool) {
+	if ctx == nil {
+		return
+	}
+	actor, ok = ctx.Value(auditActorKey{}).(string)
+	return
+}
+
+// Audit audit registry
+var Audit = &audit{
+	TableName: AuditTableName,
+	tables:    make(map[string]struct{}, IndexCacheDefaultLength),
+}
+
+// audit type
+type audit struct {
+	// TableName audit log table name
+	TableName string
+	// list of audited tables
+	tables map[string]struct{}
+	// rw mutex
+	m sync.RWMutex
+}
+
+// AuditChange old and new column value
+type AuditChange struct {
+	// Old value
+	Old json.RawMessage `json:"old"`
+	// New value
+	New json.RawMessage `json:"new"`
+}
+
+// Register models for audit
+func (a *audit) Register(model ...IModel) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	for i := range model {
+		a.tables[model[i].Table()] = struct{}{}
+	}
+}
+
+// Unregister models from audit
+func (a *audit) Unregister(model ...IModel) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	for i := range model {
+		delete(a.tables, model[i].Table())
+	}
+}
+
+// IsAudited check if model registered for audit
+func (a *audit) IsAudited(model IModel) bool {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	_, ok := a.tables[model.Table()]
+	return ok
+}
+
+// track run operation and write audit log row if model is audited
+// io - IndexOperationSave or IndexOperationDelete
+func (a *audit) track(ctx context.Context, q godb.Queryer, model IModel, io IndexOperation, fn func() porterr.IError) porterr.IError {
+	if !a.IsAudited(model) {
+		return fn()
+	}
+	old, e := snapshot(q, model)
+	if e != nil {
+		return e
+	}
+	e = fn()
+	if e != nil {
+		return e
+	}
+	current, e := snapshot(q, model)
+	if e != nil {
+		return e
+	}
+	if io == IndexOperationSave {
+		if old == nil {
+			io = IndexOperationCreate
+		} else {
+			io = IndexOperationUpdate
+		}
+	}
+	diff, err := AuditDiff(old, current)
+	if err != nil {
+		return porterr.New(porterr.PortErrorEncoder, "Audit diff error: "+err.Error())
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	return a.write(ctx, q, model, io, diff)
+}
+
+// write audit log row
+func (a *audit) write(ctx context.Context, q godb.Queryer, model IModel, io IndexOperation, diff map[string]AuditChange) porterr.IError {
+	pk, err := keyJSON(model)
+	if err != nil {
+		return porterr.New(porterr.PortErrorEncoder, "Audit primary key error: "+err.Error())
+	}
+	changes, err := json.Marshal(diff)
+	if err != nil {
+		return porterr.New(porterr.PortErrorEncoder, "Audit diff error: "+err.Error())
+	}
+	var actor *string
+	if v, ok := ActorFromContext(ctx); ok {
+		actor = &v
+	}
+	insert := gosql.NewInsert().Into(a.TableName)
+	insert.Columns().Add("table_name", "primary_key", "operation", "actor", "diff")
+	insert.Columns().Arg(model.Table(), string(pk), string(io), actor, string(changes))
+	return Do(q, insert)
+}
+
+// snapshot load model row as json. Returns nil if model has no key values or row not found
+func snapshot(q godb.Queryer, model IModel) (row json.RawMessage, e porterr.IError) {
+	meta := PrepareMetaModel(model)
+	if meta == nil {
+		return
+	}
+	keys := meta.Fields.Keys()
+	if len(keys) == 0 {
+		return
+	}
+	var data []byte
+	query := gosql.NewSelect().From(model.Table() + " t")
+	query.Columns().Append("row_to_json(t)", &data)
+	for i := range keys {
+		if keys[i].IsNil {
+			return
+		}
+		if keys[i].IsArray {
+			query.Where().AddExpression("t."+keys[i].Column+" = ?", pq.Array(keys[i].Value))
+		} else {
+			query.Where().AddExpression("t."+keys[i].Column+" = ?", keys[i].Value)
+		}
+	}
+	e = Do(q, query)
+	if e != nil {
+		if e.GetHTTP() == http.StatusNotFound {
+			e = nil
+		}
+		return
+	}
+	row = data
+	return
+}
+
+// AuditDiff compare old and new rows in json format
+// Returns changed columns only
+func AuditDiff(old, current json.RawMessage) (diff map[string]AuditChange, err error) {
+	var o, c map[string]json.RawMessage
+	if old != nil {
+		if err = json.Unmarshal(old, &o); err != nil {
+			return
+		}
+	}
+	if current != nil {
+		if err = json.Unmarshal(current, &c); err != nil {
+			return
+		}
+	}
+	diff = make(map[string]AuditChange)
+	for column, value := range o {
+		if nv, ok := c[column]; !ok || !bytes.Equal(value, nv) {
+			diff[column] = AuditChange{Old: value, New: jsonNull(nv)}
+		}
+	}
+	for column, value := range c {
+		if _, ok := o[column]; !ok {
+			diff[column] = AuditChange{Old: jsonNull(nil), New: value}
+		}
+	}
+	return
+}
+
+// jsonNull json null value if empty
+func jsonNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
+
+// AuditTable init audit log table
+func AuditTable(name string) *gosql.Table {
+	table := gosql.CreateTable(name)
+	gosql.TableModeler{BigSerialPrimaryKeyModifier, AuditModifier}.Prepare(table)
+	return table
+}
+
+// AuditModifier create audit log columns
+func AuditModifier(tb *gosql.Table) {
+	tb.AddColumn("table_name").Type("TEXT").Constraint().NotNull()
+	tb.AddColumn("primary_key").Type("JSONB").Constraint().NotNull()
+	tb.AddColumn("operation").Type("TEXT").Constraint().NotNull()
+	tb.AddColumn("actor").Type("TEXT")
+	tb.AddColumn("diff").Type("JSONB").Constraint().NotNull()
+	tb.AddColumn("created_at").Type("TIMESTAMP WITH TIME ZONE").Constraint().NotNull().Default("CURRENT_TIMESTAMP")
+}
@@ -0,0 +1,172 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+func TestAuditTable(t *testing.T) {
+	query, _, _ := AuditTable(AuditTableName).SQL()
+	t.Log(query)
+	if query != "CREATE TABLE audit_log (id bigserial NOT NULL PRIMARY KEY, table_name TEXT NOT NULL, primary_key JSONB NOT NULL, operation TEXT NOT NULL, actor TEXT, diff JSONB NOT NULL, created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP);" {
+		t.Fatal("wrong audit table query")
+	}
+}
+
+func TestAuditDiff(t *testing.T) {
+	t.Run("update", func(t *testing.T) {
+		diff, err := AuditDiff(json.RawMessage(`{"id": 1, "name": "foo", "some_int": 10}`), json.RawMessage(`{"id": 1, "name": "bar", "some_int": 10}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 1 {
+			t.Fatal("wrong diff len")
+		}
+		if string(diff["name"].Old) != `"foo"` || string(diff["name"].New) != `"bar"` {
+			t.Fatal("wrong name diff")
+		}
+	})
+	t.Run("create", func(t *testing.T) {
+		diff, err := AuditDiff(nil, json.RawMessage(`{"id": 1, "name": "foo"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 2 || string(diff["id"].Old) != "null" || string(diff["id"].New) != "1" {
+			t.Fatal("wrong create diff")
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		diff, err := AuditDiff(json.RawMessage(`{"id": 1, "name": "foo"}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 2 || string(diff["name"].Old) != `"foo"` || string(diff["name"].New) != "null" {
+			t.Fatal("wrong delete diff")
+		}
+	})
+}
+
+func TestAudit(t *testing.T) {
+	m := &InsertModel1{Id: &ACMId}
+	if Audit.IsAudited(m) {
+		t.Fatal("must not be audited")
+	}
+	Audit.Register(m)
+	if !Audit.IsAudited(m) {
+		t.Fatal("must be audited")
+	}
+	Audit.Unregister(m)
+	var called bool
+	e := Audit.track(context.Background(), nil, m, IndexOperationSave, func() porterr.IError {
+		called = true
+		return nil
+	})
+	if e != nil || !called {
+		t.Fatal("operation must be called for not audited model")
+	}
+	ctx := WithActor(context.Background(), "admin")
+	if actor, ok := ActorFromContext(ctx); !ok || actor != "admin" {
+		t.Fatal("wrong actor")
+	}
+	if _, ok := ActorFromContext(context.Background()); ok {
+		t.Fatal("actor must be empty")
+	}
+}
+
+func TestAudit_track(t *testing.T) {
+	book := &RelationBook{}
+	Audit.Register(book)
+	defer Audit.Unregister(book)
+	// fakeAudit database with scripted row snapshots. nil snapshot means row not found
+	fakeAudit := func(snapshots ...[]byte) (*godb.DBO, *fakeDB) {
+		return newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			switch {
+			case strings.HasPrefix(query, "SELECT row_to_json(t)"):
+				row := snapshots[0]
+				snapshots = snapshots[1:]
+				if row == nil {
+					return fakeResult{columns: []string{"row_to_json"}}
+				}
+				return fakeResult{columns: []string{"row_to_json"}, rows: [][]driver.Value{{row}}}
+			case strings.HasPrefix(query, "INSERT INTO book"):
+				return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(7)}}}
+			}
+			return fakeResult{affected: 1}
+		})
+	}
+	const snapshotQuery = "SELECT row_to_json(t) FROM book t WHERE (t.id = ?)"
+	const auditQuery = "INSERT INTO audit_log (table_name, primary_key, operation, actor, diff) VALUES (?, ?, ?, ?, ?);"
+	// check executed queries and audit row
+	check := func(t *testing.T, fake *fakeDB, queries []string, operation string, actor any, diff string) {
+		log := fake.log()
+		if strings.Join(log, "\n") != strings.Join(queries, "\n") {
+			t.Fatal("wrong queries: " + strings.Join(log, "\n"))
+		}
+		if operation == "" {
+			return
+		}
+		args := fake.args[len(log)-1]
+		if args[0] != "book" || args[1] != `{"id":7}` || args[2] != operation || args[3] != actor || args[4] != diff {
+			t.Fatalf("wrong audit row: %v", args)
+		}
+	}
+	id, authorId, title := 7, 1, "title"
+	ctx := WithActor(context.Background(), "admin")
+	t.Run("create", func(t *testing.T) {
+		dbo, fake := fakeAudit([]byte(`{"id":7,"author_id":1,"title":"title"}`))
+		e := SaveContext(ctx, dbo, &RelationBook{AuthorId: &authorId, Title: &title})
+		if e != nil {
+			t.Fatal(e)
+		}
+		check(t, fake, []string{
+			"INSERT INTO book (author_id, title) VALUES (?, ?) RETURNING id;",
+			snapshotQuery,
+			auditQuery,
+		}, string(IndexOperationCreate), "admin", `{"author_id":{"old":null,"new":1},"id":{"old":null,"new":7},"title":{"old":null,"new":"title"}}`)
+	})
+	t.Run("update", func(t *testing.T) {
+		dbo, fake := fakeAudit([]byte(`{"id":7,"author_id":1,"title":"old"}`), []byte(`{"id":7,"author_id":1,"title":"title"}`))
+		e := SaveContext(context.Background(), dbo, &RelationBook{Id: &id, AuthorId: &authorId, Title: &title})
+		if e != nil {
+			t.Fatal(e)
+		}
+		check(t, fake, []string{
+			snapshotQuery,
+			"UPDATE book SET author_id = ?, title = ? WHERE (id = ?);",
+			snapshotQuery,
+			auditQuery,
+		}, string(IndexOperationUpdate), nil, `{"title":{"old":"old","new":"title"}}`)
+	})
+	t.Run("unchanged", func(t *testing.T) {
+		row := []byte(`{"id":7,"author_id":1,"title":"title"}`)
+		dbo, fake := fakeAudit(row, row)
+		e := SaveContext(ctx, dbo, &RelationBook{Id: &id, AuthorId: &authorId, Title: &title})
+		if e != nil {
+			t.Fatal(e)
+		}
+		check(t, fake, []string{
+			snapshotQuery,
+			"UPDATE book SET author_id = ?, title = ? WHERE (id = ?);",
+			snapshotQuery,
+		}, "", nil, "")
+	})
+	t.Run("delete", func(t *testing.T) {
+		dbo, fake := fakeAudit([]byte(`{"id":7,"author_id":1,"title":"title"}`), nil)
+		e := DeleteContext(ctx, dbo, &RelationBook{Id: &id})
+		if e != nil {
+			t.Fatal(e)
+		}
+		check(t, fake, []string{
+			snapshotQuery,
+			"DELETE FROM book WHERE (id = ?);",
+			snapshotQuery,
+			auditQuery,
+		}, string(IndexOperationDelete), "admin", `{"author_id":{"old":1,"new":null},"id":{"old":7,"new":null},"title":{"old":"title","new":null}}`)
+	})
+}
@@ -0,0 +1,418 @@
+package gomodel
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// CascadeDefaultDepth default max depth of cascade soft delete
+const CascadeDefaultDepth = 5
+
+// CascadeRule soft table referencing parent table by foreign key
+type CascadeRule struct {
+	// Table dependent table
+	Table string
+	// Column foreign key column of dependent table
+	Column string
+	// ParentTable referenced table
+	ParentTable string
+	// ParentColumn referenced column of parent table
+	ParentColumn string
+	// DeletedColumn deleted at column of dependent table
+	DeletedColumn string
+	// UpdatedColumn updated at column of dependent table. Optional
+	UpdatedColumn string
+}
+
+// CascadeStep dependent table affected by cascade
+type CascadeStep struct {
+	CascadeRule
+	// Depth level of dependency. 1 for direct children of model
+	Depth int
+}
+
+// Cascade cascade soft delete registry
+var Cascade = &cascade{
+	maxDepth: CascadeDefaultDepth,
+	rules:    make(map[string][]CascadeRule, IndexCacheDefaultLength),
+}
+
+// cascade type
+type cascade struct {
+	// max depth of dependencies. 0 disables cascade
+	maxDepth int
+	// rules by parent table
+	rules map[string][]CascadeRule
+	// rw mutex
+	m sync.RWMutex
+}
+
+// cascadeTable table with schema
+func cascadeTable(table string) string {
+	if !strings.Contains(table, ".") {
+		return DefaultSchema + "." + table
+	}
+	return table
+}
+
+// RegisterRule register dependent tables
+func (c *cascade) RegisterRule(rule ...CascadeRule) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for i := range rule {
+		parent := cascadeTable(rule[i].ParentTable)
+		var exists bool
+		for j := range c.rules[parent] {
+			if c.rules[parent][j].Table == rule[i].Table && c.rules[parent][j].Column == rule[i].Column {
+				c.rules[parent][j], exists = rule[i], true
+			}
+		}
+		if !exists {
+			c.rules[parent] = append(c.rules[parent], rule[i])
+		}
+	}
+}
+
+// Register dependent soft models. Rules are derived from frk tags with one referenced column
+func (c *cascade) Register(model ...IModel) {
+	for i := range model {
+		meta := PrepareMetaModel(model[i])
+		if meta == nil {
+			continue
+		}
+		var deleted, updated string
+		for j := range meta.Fields {
+			if meta.Fields[j].IsDeletedAt {
+				deleted = meta.Fields[j].Column
+			} else if meta.Fields[j].IsUpdatedAt {
+				updated = meta.Fields[j].Column
+			}
+		}
+		if deleted == "" {
+			continue
+		}
+		for j := range meta.Fields {
+			if table, columns := meta.Fields[j].ForeignReference(); len(columns) == 1 {
+				c.RegisterRule(CascadeRule{
+					Table:         meta.TableName,
+					Column:        meta.Fields[j].Column,
+					ParentTable:   table,
+					ParentColumn:  columns[0],
+					DeletedColumn: deleted,
+					UpdatedColumn: updated,
+				})
+			}
+		}
+	}
+}
+
+// RegisterColumns register dependent table by generator columns
+// Columns with foreign key to soft table are used. Table must have deleted at column
+func (c *cascade) RegisterColumns(columns Columns) {
+	var deleted, updated string
+	for i := range columns {
+		if columns[i].IsDeleted {
+			deleted = columns[i].Name
+		} else if columns[i].IsUpdated {
+			updated = columns[i].Name
+		}
+	}
+	if deleted == "" {
+		return
+	}
+	for i := range columns {
+		if columns[i].ForeignTable == nil || columns[i].ForeignColumnName == nil || !columns[i].ForeignIsSoft {
+			continue
+		}
+		parent := *columns[i].ForeignTable
+		if columns[i].ForeignSchema != nil {
+			parent = *columns[i].ForeignSchema + "." + parent
+		}
+		c.RegisterRule(CascadeRule{
+			Table:         columns[i].Schema + "." + columns[i].Table,
+			Column:        columns[i].Name,
+			ParentTable:   parent,
+			ParentColumn:  *columns[i].ForeignColumnName,
+			DeletedColumn: deleted,
+			UpdatedColumn: updated,
+		})
+	}
+}
+
+// Unregister dependent tables
+func (c *cascade) Unregister(table ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for parent := range c.rules {
+		rules := c.rules[parent][:0]
+		for _, rule := range c.rules[parent] {
+			var found bool
+			for i := range table {
+				if isSameTable(rule.Table, table[i]) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == 0 {
+			delete(c.rules, parent)
+		} else {
+			c.rules[parent] = rules
+		}
+	}
+}
+
+// Rules registered rules of parent table
+func (c *cascade) Rules(table string) []CascadeRule {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return append([]CascadeRule(nil), c.rules[cascadeTable(table)]...)
+}
+
+// SetDepth set max depth of dependencies. 0 disables cascade
+func (c *cascade) SetDepth(depth int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.maxDepth = depth
+}
+
+// depth max depth of dependencies
+func (c *cascade) depth() int {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.maxDepth
+}
+
+// DryRun list dependent tables affected by delete or restore of model
+// Nothing is executed. Tables are listed level by level up to max depth
+func (c *cascade) DryRun(model IModel) (steps []CascadeStep) {
+	tables := []string{model.Table()}
+	for depth := 1; depth <= c.depth() && len(tables) > 0; depth++ {
+		var next []string
+		for _, table := range tables {
+			for _, rule := range c.Rules(table) {
+				steps = append(steps, CascadeStep{CascadeRule: rule, Depth: depth})
+				next = append(next, rule.Table)
+			}
+		}
+		tables = next
+	}
+	return
+}
+
+// isCascaded check if cascade is used for model
+// Cascade is used for soft models with registered dependent tables only
+func (c *cascade) isCascaded(model IModel) bool {
+	meta := PrepareMetaModel(model)
+	return c.depth() > 0 && meta != nil && meta.Fields.IsSoft() && len(c.Rules(model.Table())) > 0
+}
+
+// run operation fn and cascade it to dependent tables in one transaction
+func (c *cascade) run(q godb.Queryer, model IModel, restore bool, fn func(q godb.Queryer) porterr.IError) porterr.IError {
+	if !c.isCascaded(model) {
+		return fn(q)
+	}
+	return InTx(q, func(tx godb.Queryer) porterr.IError {
+		var deletedAt *time.Time
+		var e porterr.IError
+		if restore {
+			if deletedAt, e = softDeletedAt(tx, model, PrepareMetaModel(model)); e != nil {
+				return e
+			}
+		}
+		if e = fn(tx); e != nil || (restore && deletedAt == nil) {
+			return e
+		}
+		return c.cascade(tx, model.Table(), c.parentKeys(model), deletedAt, 1)
+	}, TxOptions{})
+}
+
+// runItems delete models with fn and cascade soft delete to dependent tables in one transaction
+// models - models of one table
+func (c *cascade) runItems(q godb.Queryer, models []IModel, fn func(q godb.Queryer) porterr.IError) porterr.IError {
+	if len(models) == 0 || !c.isCascaded(models[0]) {
+		return fn(q)
+	}
+	return InTx(q, func(tx godb.Queryer) porterr.IError {
+		if e := fn(tx); e != nil {
+			return e
+		}
+		return c.cascade(tx, models[0].Table(), c.parentKeys(models...), nil, 1)
+	}, TxOptions{})
+}
+
+// runWhere exec soft delete of table rows and cascade it to dependent tables in one transaction
+// Referenced columns of rules are added to returning of update
+func (c *cascade) runWhere(q godb.Queryer, table string, update *gosql.Update) (count int64, e porterr.IError) {
+	rules := c.Rules(table)
+	if c.depth() <= 0 || len(rules) == 0 {
+		return execCount(q, update)
+	}
+	var columns []string
+	for _, rule := range rules {
+		var exists bool
+		for i := range columns {
+			exists = exists || columns[i] == rule.ParentColumn
+		}
+		if !exists {
+			columns = append(columns, rule.ParentColumn)
+			update.Returning().Add(rule.ParentColumn)
+		}
+	}
+	e = InTx(q, func(tx godb.Queryer) porterr.IError {
+		var keys map[string][]any
+		if keys, count, e = cascadeKeys(tx, update, columns); e != nil {
+			return e
+		}
+		return c.cascade(tx, table, keys, nil, 1)
+	}, TxOptions{})
+	return
+}
+
+// parentKeys referenced key values of models by parent column of rules
+// models - models of one table
+func (c *cascade) parentKeys(models ...IModel) map[string][]any {
+	keys := make(map[string][]any)
+	if len(models) == 0 {
+		return keys
+	}
+	for _, rule := range c.Rules(models[0].Table()) {
+		if _, ok := keys[rule.ParentColumn]; ok {
+			continue
+		}
+		for _, model := range models {
+			if field := PrepareMetaModel(model).Fields.ByColumn(rule.ParentColumn); field != nil {
+				if key, ok := relationKey(field.Value); ok {
+					keys[rule.ParentColumn] = append(keys[rule.ParentColumn], key)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// cascade soft delete or restore dependent rows of table
+// keys - referenced key values by parent column
+// deletedAt - deleted at of restored parent. Nil for delete
+func (c *cascade) cascade(q godb.Queryer, table string, keys map[string][]any, deletedAt *time.Time, depth int) porterr.IError {
+	if depth > c.depth() {
+		return nil
+	}
+	for _, rule := range c.Rules(table) {
+		if len(keys[rule.ParentColumn]) == 0 {
+			continue
+		}
+		update, returning := c.getCascadeSQL(rule, keys[rule.ParentColumn], deletedAt, depth)
+		if len(returning) == 0 {
+			if _, e := execCount(q, update); e != nil {
+				return e
+			}
+			continue
+		}
+		next, _, e := cascadeKeys(q, update, returning)
+		if e != nil {
+			return e
+		}
+		if e = c.cascade(q, rule.Table, next, deletedAt, depth+1); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// getCascadeSQL soft delete or restore query of dependent rows
+// Returns referenced columns of next level rules
+func (c *cascade) getCascadeSQL(rule CascadeRule, keys []any, deletedAt *time.Time, depth int) (*gosql.Update, []string) {
+	update := gosql.NewUpdate().Table(rule.Table)
+	update.Where().AddExpression(rule.Column+" = ANY(?)", pq.Array(keys))
+	if deletedAt == nil {
+		expr, args := setNow(rule.DeletedColumn)
+		update.Set().Append(expr, args...)
+		update.Where().AddExpression(rule.DeletedColumn + " IS NULL")
+	} else {
+		// rows deleted by parent cascade have the same deleted at
+		update.Set().Append(rule.DeletedColumn + " = NULL")
+		update.Where().AddExpression(rule.DeletedColumn+" = ?", *deletedAt)
+	}
+	if rule.UpdatedColumn != "" {
+		expr, args := setNow(rule.UpdatedColumn)
+		update.Set().Append(expr, args...)
+	}
+	var returning []string
+	if depth < c.depth() {
+		for _, next := range c.Rules(rule.Table) {
+			var exists bool
+			for i := range returning {
+				exists = exists || returning[i] == next.ParentColumn
+			}
+			if !exists {
+				returning = append(returning, next.ParentColumn)
+				update.Returning().Add(next.ParentColumn)
+			}
+		}
+	}
+	return update, returning
+}
+
+// cascadeKeys exec cascade query and collect returned key values by column
+// Returns count of updated rows
+func cascadeKeys(q godb.Queryer, update *gosql.Update, columns []string) (map[string][]any, int64, porterr.IError) {
+	query, params, _ := update.SQL()
+	rows, err := q.Query(query, bindClock(q, params)...)
+	if err != nil {
+		return nil, 0, NewDatabaseError(err)
+	}
+	defer func() { _ = rows.Close() }()
+	keys := make(map[string][]any, len(columns))
+	values := make([]any, len(columns))
+	var count int64
+	for rows.Next() {
+		count++
+		for i := range values {
+			values[i] = new(any)
+		}
+		if err = rows.Scan(values...); err != nil {
+			return nil, 0, porterr.New(porterr.PortErrorIO, "Cascade scan error: "+err.Error())
+		}
+		for i := range columns {
+			switch v := (*(values[i].(*any))).(type) {
+			case nil:
+			case []byte:
+				keys[columns[i]] = append(keys[columns[i]], string(v))
+			default:
+				keys[columns[i]] = append(keys[columns[i]], v)
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, NewDatabaseError(err)
+	}
+	return keys, count, nil
+}
+
+// softDeletedAt current deleted at of soft model row
+func softDeletedAt(q godb.Queryer, model IModel, meta *MetaModel) (deletedAt *time.Time, e porterr.IError) {
+	idx := InitIndex(meta.Fields.Len())
+	query := gosql.NewSelect().From(model.Table())
+	cond := restoreCondition(meta, &idx)
+	if cond.IsEmpty() {
+		return nil, porterr.New(porterr.PortErrorArgument, "Model "+model.Table()+" has no key values")
+	}
+	query.Where().Replace(cond)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsDeletedAt {
+			query.Columns().Append(meta.Fields[i].Column, &deletedAt)
+		}
+	}
+	e = Do(q, query)
+	return
+}
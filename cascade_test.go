@@ -0,0 +1,277 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+)
+
+type CascadePost struct {
+	Id        *int       `json:"id" db:"col~id;prk;req;seq;"`
+	Title     *string    `json:"title" db:"col~title;req;"`
+	UpdatedAt *time.Time `json:"updatedAt" db:"col~updated_at;uat;"`
+	DeletedAt *time.Time `json:"deletedAt" db:"col~deleted_at;dat;"`
+}
+
+// Model table name
+func (m *CascadePost) Table() string { return "cascade_post" }
+
+// Model columns
+func (m *CascadePost) Columns() []string {
+	return []string{"id", "title", "updated_at", "deleted_at"}
+}
+
+// Model values
+func (m *CascadePost) Values() []any {
+	return []any{&m.Id, &m.Title, &m.UpdatedAt, &m.DeletedAt}
+}
+
+type CascadeComment struct {
+	Id        *int       `json:"id" db:"col~id;prk;req;seq;"`
+	PostId    *int       `json:"postId" db:"col~post_id;frk~public.cascade_post.id;req;"`
+	DeletedAt *time.Time `json:"deletedAt" db:"col~deleted_at;dat;"`
+}
+
+// Model table name
+func (m *CascadeComment) Table() string { return "cascade_comment" }
+
+// Model columns
+func (m *CascadeComment) Columns() []string {
+	return []string{"id", "post_id", "deleted_at"}
+}
+
+// Model values
+func (m *CascadeComment) Values() []any {
+	return []any{&m.Id, &m.PostId, &m.DeletedAt}
+}
+
+func TestCascade_Register(t *testing.T) {
+	defer Cascade.Unregister("cascade_comment", "public.cascade_like")
+	Cascade.Register(&CascadeComment{}, &RelationBook{})
+	schema, table, column := "public", "cascade_comment", "id"
+	Cascade.RegisterColumns(Columns{
+		{Name: "id", Schema: "public", Table: "cascade_like"},
+		{Name: "comment_id", Schema: "public", Table: "cascade_like", ForeignSchema: &schema, ForeignTable: &table, ForeignColumnName: &column, ForeignIsSoft: true},
+		{Name: "deleted_at", Schema: "public", Table: "cascade_like", IsDeleted: true},
+	})
+	if len(Cascade.Rules("author")) != 0 {
+		t.Fatal("not soft model must not be registered")
+	}
+	rules := Cascade.Rules("public.cascade_post")
+	if len(rules) != 1 || rules[0].Table != "cascade_comment" || rules[0].Column != "post_id" || rules[0].DeletedColumn != "deleted_at" {
+		t.Fatal("wrong rule from model")
+	}
+	steps := Cascade.DryRun(&CascadePost{})
+	if len(steps) != 2 || steps[0].Depth != 1 || steps[1].Depth != 2 || steps[1].Table != "public.cascade_like" || steps[1].ParentColumn != "id" {
+		t.Fatal("wrong dry run steps")
+	}
+	Cascade.SetDepth(1)
+	if len(Cascade.DryRun(&CascadePost{})) != 1 {
+		t.Fatal("dry run must be limited by depth")
+	}
+	update, returning := Cascade.getCascadeSQL(rules[0], []any{1}, nil, 1)
+	query, _, _ := update.SQL()
+	if query != "UPDATE cascade_comment SET deleted_at = NOW() WHERE (post_id = ANY(?) AND deleted_at IS NULL);" || len(returning) != 0 {
+		t.Fatal("wrong last level cascade query: " + query)
+	}
+	Cascade.SetDepth(CascadeDefaultDepth)
+	deletedAt := time.Now()
+	update, returning = Cascade.getCascadeSQL(rules[0], []any{1}, &deletedAt, 1)
+	query, params, _ := update.SQL()
+	if query != "UPDATE cascade_comment SET deleted_at = NULL WHERE (post_id = ANY(?) AND deleted_at = ?) RETURNING id;" || len(returning) != 1 || len(params) != 2 {
+		t.Fatal("wrong restore cascade query: " + query)
+	}
+	Cascade.Unregister("cascade_comment")
+	if len(Cascade.Rules("cascade_post")) != 0 {
+		t.Fatal("rule must be unregistered")
+	}
+}
+
+func TestGetRestoreSQL(t *testing.T) {
+	id := 1
+	for i := 0; i < 2; i++ {
+		query, params, returning := GetRestoreSQL(&CascadePost{Id: &id}).SQL()
+		if query != "UPDATE cascade_post SET updated_at = NOW(), deleted_at = NULL WHERE (id = ?) RETURNING updated_at, deleted_at;" {
+			t.Fatal("wrong restore query: " + query)
+		}
+		if len(params) != 1 || len(returning) != 2 {
+			t.Fatal("wrong restore params or returning")
+		}
+	}
+	if GetRestoreSQL(&RelationBook{Id: &id}) != nil {
+		t.Fatal("not soft model must not be restored")
+	}
+}
+
+func TestCascade_run(t *testing.T) {
+	Cascade.Register(&CascadeComment{})
+	defer Cascade.Unregister("cascade_comment")
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dbo, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.HasPrefix(query, "UPDATE cascade_post"):
+			return fakeResult{columns: []string{"updated_at", "deleted_at"}, rows: [][]driver.Value{{deletedAt, deletedAt}}}
+		case strings.HasPrefix(query, "SELECT deleted_at FROM cascade_post"):
+			return fakeResult{columns: []string{"deleted_at"}, rows: [][]driver.Value{{deletedAt}}}
+		}
+		return fakeResult{affected: 2}
+	})
+	id := 1
+	post := &CascadePost{Id: &id}
+	if e := Delete(dbo, post); e != nil {
+		t.Fatal(e)
+	}
+	expected := []string{
+		"BEGIN",
+		"UPDATE cascade_post SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ?) RETURNING updated_at, deleted_at;",
+		"UPDATE cascade_comment SET deleted_at = NOW() WHERE (post_id = ANY(?) AND deleted_at IS NULL);",
+		"COMMIT",
+	}
+	if log := fake.log(); strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Fatal("wrong cascade delete queries: " + strings.Join(log, "\n"))
+	}
+	if e := Restore(dbo, post); e != nil {
+		t.Fatal(e)
+	}
+	expected = append(expected,
+		"BEGIN",
+		"SELECT deleted_at FROM cascade_post WHERE (id = ?)",
+		"UPDATE cascade_post SET updated_at = NOW(), deleted_at = NULL WHERE (id = ?) RETURNING updated_at, deleted_at;",
+		"UPDATE cascade_comment SET deleted_at = NULL WHERE (post_id = ANY(?) AND deleted_at = ?);",
+		"COMMIT",
+	)
+	log := fake.log()
+	if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Fatal("wrong cascade restore queries: " + strings.Join(log, "\n"))
+	}
+	if args := fake.args[len(log)-2]; len(args) != 2 || args[1] != deletedAt {
+		t.Fatal("children must be restored by deleted at of parent")
+	}
+}
+
+func TestCascade_collection(t *testing.T) {
+	Cascade.Register(&CascadeComment{})
+	defer Cascade.Unregister("cascade_comment")
+	now := time.Now()
+	dbo, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.HasPrefix(query, "UPDATE cascade_post SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ANY(?))"):
+			return fakeResult{columns: []string{"id", "updated_at", "deleted_at"}, rows: [][]driver.Value{{int64(1), now, now}, {int64(2), now, now}}}
+		case strings.HasPrefix(query, "UPDATE cascade_post"):
+			return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}, {int64(4)}}}
+		}
+		return fakeResult{affected: 1}
+	})
+	c := NewCollection[CascadePost]()
+	for i := 1; i <= 2; i++ {
+		id := i
+		c.AddItem(&CascadePost{Id: &id})
+	}
+	if e := c.Delete(dbo); e != nil {
+		t.Fatal(e)
+	}
+	expected := []string{
+		"BEGIN",
+		"UPDATE cascade_post SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ANY(?)) RETURNING id, updated_at, deleted_at;",
+		"UPDATE cascade_comment SET deleted_at = NOW() WHERE (post_id = ANY(?) AND deleted_at IS NULL);",
+		"COMMIT",
+	}
+	if log := fake.log(); strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Fatal("wrong collection cascade queries: " + strings.Join(log, "\n"))
+	}
+	if keys := fake.args[2][0].(string); keys != "{1,2}" {
+		t.Fatal("wrong cascade keys of items: " + keys)
+	}
+	where := NewCollection[CascadePost]()
+	where.Where().AddExpression("title = ?", "foo")
+	count, e := where.DeleteWhere(dbo)
+	if e != nil || count != 2 {
+		t.Fatal("wrong delete where count")
+	}
+	expected = append(expected,
+		"BEGIN",
+		"UPDATE cascade_post SET updated_at = NOW(), deleted_at = NOW() WHERE ((title = ?)) RETURNING id;",
+		"UPDATE cascade_comment SET deleted_at = NOW() WHERE (post_id = ANY(?) AND deleted_at IS NULL);",
+		"COMMIT",
+	)
+	log := fake.log()
+	if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Fatal("wrong delete where cascade queries: " + strings.Join(log, "\n"))
+	}
+	if keys := fake.args[len(log)-2][0].(string); keys != "{3,4}" {
+		t.Fatal("wrong cascade keys of deleted rows: " + keys)
+	}
+}
+
+func TestCascade_runWhere(t *testing.T) {
+	// fakePosts database with deleted post ids. Update of comments returns err
+	fakePosts := func(err error) (*godb.DBO, *fakeDB) {
+		return newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			switch {
+			case strings.HasPrefix(query, "UPDATE cascade_post") && strings.Contains(query, "RETURNING"):
+				return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}, {int64(4)}}}
+			case strings.HasPrefix(query, "UPDATE cascade_comment"):
+				return fakeResult{affected: 5, err: err}
+			}
+			return fakeResult{affected: 2}
+		})
+	}
+	where := NewCollection[CascadePost]()
+	where.Where().AddExpression("title = ?", "foo")
+	t.Run("without_rules", func(t *testing.T) {
+		dbo, fake := fakePosts(nil)
+		if count, e := where.DeleteWhere(dbo); e != nil || count != 2 {
+			t.Fatal("wrong delete where count")
+		}
+		if log := fake.log(); len(log) != 1 || log[0] != "UPDATE cascade_post SET updated_at = NOW(), deleted_at = NOW() WHERE ((title = ?));" {
+			t.Fatal("rows must be deleted without transaction: " + strings.Join(log, "\n"))
+		}
+	})
+	Cascade.Register(&CascadeComment{})
+	defer Cascade.Unregister("cascade_comment")
+	t.Run("zero_depth", func(t *testing.T) {
+		Cascade.SetDepth(0)
+		defer Cascade.SetDepth(CascadeDefaultDepth)
+		dbo, fake := fakePosts(nil)
+		if count, e := where.DeleteWhere(dbo); e != nil || count != 2 || len(fake.log()) != 1 {
+			t.Fatal("rows must be deleted without cascade")
+		}
+	})
+	t.Run("rules", func(t *testing.T) {
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		SetClock(FrozenClock(now))
+		defer SetClock(nil)
+		dbo, fake := fakePosts(nil)
+		if count, e := where.DeleteWhere(dbo); e != nil || count != 2 {
+			t.Fatal("wrong delete where count")
+		}
+		expected := []string{
+			"BEGIN",
+			"UPDATE cascade_post SET updated_at = ?, deleted_at = ? WHERE ((title = ?)) RETURNING id;",
+			"UPDATE cascade_comment SET deleted_at = ? WHERE (post_id = ANY(?) AND deleted_at IS NULL);",
+			"COMMIT",
+		}
+		if log := fake.log(); strings.Join(log, "\n") != strings.Join(expected, "\n") {
+			t.Fatal("wrong delete where cascade queries: " + strings.Join(log, "\n"))
+		}
+		if args := fake.args[1]; args[0] != now || args[1] != now || args[2] != "foo" {
+			t.Fatal("parent must be deleted at clock time")
+		}
+		if args := fake.args[2]; args[0] != now || args[1] != "{3,4}" {
+			t.Fatal("children of deleted rows must be deleted at clock time")
+		}
+	})
+	t.Run("rollback", func(t *testing.T) {
+		dbo, fake := fakePosts(errors.New("comment error"))
+		if _, e := where.DeleteWhere(dbo); e == nil {
+			t.Fatal("cascade error must be returned")
+		}
+		if log := fake.log(); log[len(log)-1] != "ROLLBACK" {
+			t.Fatal("transaction must be rolled back: " + strings.Join(log, "\n"))
+		}
+	})
+}
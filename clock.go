@@ -0,0 +1,137 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+)
+
+// clockPos parameter position of application clock value in Index
+const clockPos int16 = -1
+
+// clock application clock. Database NOW() is used if not set
+var clock struct {
+	// current time function
+	now func() time.Time
+	// pinned time of transactions started by InTx
+	pinned map[*godb.SqlTx]time.Time
+	// rw mutex
+	m sync.RWMutex
+}
+
+// SetClock set application clock for system timestamps
+// Updated at and deleted at values are bound as query parameters instead of NOW()
+// nil restores database NOW(). Query cache is reset
+func SetClock(now func() time.Time) {
+	clock.m.Lock()
+	clock.now = now
+	clock.m.Unlock()
+	IndexCache.Reset()
+}
+
+// FrozenClock clock always returning t. Use in tests with SetClock
+func FrozenClock(t time.Time) func() time.Time {
+	return func() time.Time {
+		return t
+	}
+}
+
+// Now current time of application clock. time.Now if clock is not set
+func Now() time.Time {
+	clock.m.RLock()
+	defer clock.m.RUnlock()
+	if clock.now == nil {
+		return time.Now()
+	}
+	return clock.now()
+}
+
+// isClockBound check if application clock is set
+func isClockBound() bool {
+	clock.m.RLock()
+	defer clock.m.RUnlock()
+	return clock.now != nil
+}
+
+// clockValue query parameter of current time of application clock in UTC
+// Time is bound on query execution by bindClock, so cached queries get actual time
+type clockValue struct{}
+
+// Value implementation of driver.Valuer. Used if query is executed without bindClock
+func (clockValue) Value() (driver.Value, error) {
+	return Now().UTC(), nil
+}
+
+// pinClock pin current time of application clock for transaction
+// All queries of transaction get the same time. Returns unpin function
+func pinClock(tx *godb.SqlTx) func() {
+	if !isClockBound() {
+		return func() {}
+	}
+	now := Now().UTC()
+	clock.m.Lock()
+	defer clock.m.Unlock()
+	if _, ok := clock.pinned[tx]; ok {
+		return func() {}
+	}
+	if clock.pinned == nil {
+		clock.pinned = make(map[*godb.SqlTx]time.Time)
+	}
+	clock.pinned[tx] = now
+	return func() {
+		clock.m.Lock()
+		delete(clock.pinned, tx)
+		clock.m.Unlock()
+	}
+}
+
+// clockTime time of application clock for query. Pinned time for transactions started by InTx
+func clockTime(q godb.Queryer) time.Time {
+	var tx *godb.SqlTx
+	switch db := q.(type) {
+	case *Tx:
+		tx = db.SqlTx
+	case *godb.SqlTx:
+		tx = db
+	}
+	if tx != nil {
+		clock.m.RLock()
+		now, ok := clock.pinned[tx]
+		clock.m.RUnlock()
+		if ok {
+			return now
+		}
+	}
+	return Now().UTC()
+}
+
+// bindClock replace clock parameters with one time of application clock
+// Time is read once per query, so updated at and deleted at of one operation are equal
+func bindClock(q godb.Queryer, params []any) []any {
+	var bound []any
+	var now time.Time
+	for i := range params {
+		if _, ok := params[i].(clockValue); !ok {
+			continue
+		}
+		if bound == nil {
+			bound, now = append([]any(nil), params...), clockTime(q)
+		}
+		bound[i] = now
+	}
+	if bound == nil {
+		return params
+	}
+	return bound
+}
+
+// setNow expression setting column to current time
+// Returns clock parameter if application clock is set
+func setNow(column string) (string, []any) {
+	if isClockBound() {
+		return column + " = ?", []any{clockValue{}}
+	}
+	return column + " = NOW()", nil
+}
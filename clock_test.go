@@ -0,0 +1,134 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clockParams positions of clock parameters
+func clockParams(params []any) (positions []int) {
+	for i := range params {
+		if _, ok := params[i].(clockValue); ok {
+			positions = append(positions, i)
+		}
+	}
+	return
+}
+
+func TestSetClock(t *testing.T) {
+	frozen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("UTC+3", 3*3600))
+	SetClock(FrozenClock(frozen))
+	defer SetClock(nil)
+	if !Now().Equal(frozen) {
+		t.Fatal("wrong frozen time")
+	}
+	value, _ := clockValue{}.Value()
+	if value.(time.Time) != frozen.UTC() {
+		t.Fatal("clock value must be in UTC")
+	}
+	id := 1
+	t.Run("update", func(t *testing.T) {
+		model := &InsertModel1{Id: &id}
+		for i := 0; i < 2; i++ {
+			query, params, _ := GetUpdateSQL(model, &model.Id, &model.Name, &model.UpdatedAt).SQL()
+			if query != "UPDATE test_model_1 SET name = ?, updated_at = ? WHERE (id = ?) RETURNING updated_at;" {
+				t.Fatal("wrong update query: " + query)
+			}
+			if positions := clockParams(params); len(positions) != 1 || positions[0] != 1 {
+				t.Fatal("wrong clock param position")
+			}
+		}
+	})
+	t.Run("save", func(t *testing.T) {
+		model := &InsertModel1{Id: &id}
+		for i := 0; i < 2; i++ {
+			query, params, _ := GetSaveSQL(model).SQL()
+			if query != "UPDATE test_model_1 SET name = ?, pages = ?, some_int = ?, updated_at = ? WHERE (id = ?) RETURNING created_at, updated_at, deleted_at;" {
+				t.Fatal("wrong save query: " + query)
+			}
+			if positions := clockParams(params); len(positions) != 1 || positions[0] != 3 {
+				t.Fatal("wrong clock param position")
+			}
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		model := &InsertModel1{Id: &id}
+		for i := 0; i < 2; i++ {
+			query, params, _ := GetDeleteSQL(model).SQL()
+			if query != "UPDATE test_model_1 SET updated_at = ?, deleted_at = ? WHERE (id = ?) RETURNING updated_at, deleted_at;" {
+				t.Fatal("wrong delete query: " + query)
+			}
+			if positions := clockParams(params); len(positions) != 2 || positions[1] != 1 {
+				t.Fatal("wrong clock param position")
+			}
+			if v, ok := params[2].(**int); !ok || *v != model.Id {
+				t.Fatal("wrong key param")
+			}
+		}
+	})
+	t.Run("restore", func(t *testing.T) {
+		model := &CascadePost{Id: &id}
+		for i := 0; i < 2; i++ {
+			query, params, _ := GetRestoreSQL(model).SQL()
+			if query != "UPDATE cascade_post SET updated_at = ?, deleted_at = NULL WHERE (id = ?) RETURNING updated_at, deleted_at;" {
+				t.Fatal("wrong restore query: " + query)
+			}
+			if positions := clockParams(params); len(positions) != 1 || positions[0] != 0 {
+				t.Fatal("wrong clock param position")
+			}
+		}
+	})
+	SetClock(nil)
+	query, _, _ := GetDeleteSQL(&InsertModel1{Id: &id}).SQL()
+	if query != "UPDATE test_model_1 SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ?) RETURNING updated_at, deleted_at;" {
+		t.Fatal("database clock must be restored: " + query)
+	}
+	if _, ok := interface{}(clockValue{}).(driver.Valuer); !ok {
+		t.Fatal("clock value must be driver valuer")
+	}
+}
+
+func TestBindClock(t *testing.T) {
+	var ticks int
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time {
+		ticks++
+		return start.Add(time.Duration(ticks) * time.Second)
+	})
+	defer SetClock(nil)
+	Cascade.Register(&CascadeComment{})
+	defer Cascade.Unregister("cascade_comment")
+	dbo, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		if strings.HasPrefix(query, "UPDATE test_model_1") || strings.HasPrefix(query, "UPDATE cascade_post") {
+			return fakeResult{columns: []string{"updated_at", "deleted_at"}, rows: [][]driver.Value{{start, start}}}
+		}
+		return fakeResult{affected: 1}
+	})
+	id := 1
+	if e := Delete(dbo, &InsertModel1{Id: &id}); e != nil {
+		t.Fatal(e)
+	}
+	if args := fake.args[0]; args[0] != args[1] {
+		t.Fatal("updated at and deleted at of one query must be equal")
+	}
+	if e := Delete(dbo, &CascadePost{Id: &id}); e != nil {
+		t.Fatal(e)
+	}
+	log := fake.log()
+	if len(log) != 5 || log[1] != "BEGIN" || log[4] != "COMMIT" {
+		t.Fatal("wrong cascade queries: " + strings.Join(log, "\n"))
+	}
+	parent, child := fake.args[2], fake.args[3]
+	if parent[0] != parent[1] || child[0] != parent[1] {
+		t.Fatal("children must be deleted at time of parent")
+	}
+	if parent[1] == fake.args[0][1] {
+		t.Fatal("time of next operation must be read again")
+	}
+	params := bindClock(dbo, []any{clockValue{}, 1, clockValue{}})
+	if params[0] != params[2] || params[1] != 1 {
+		t.Fatal("wrong bound params")
+	}
+}
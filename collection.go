@@ -1,11 +1,13 @@
 package gomodel
 
 import (
+	"context"
 	"database/sql"
 	"github.com/dimonrus/godb/v2"
 	"github.com/dimonrus/gohelp"
 	"github.com/dimonrus/gosql"
 	"github.com/dimonrus/porterr"
+	"time"
 )
 
 // Collection struct contain items and collection common methods
@@ -18,6 +20,22 @@ type Collection[T any] struct {
 	*gosql.Select
 	// Count
 	CountOver int
+	// collection scoped by tenant
+	scoped bool
+	// keyset pagination state
+	keyset *keyset
+	// fetch size of server-side cursor
+	fetchSize int
+	// template model for field addressing
+	model *T
+	// values of computed columns by item
+	computed map[*T]map[string]any
+	// work queue options
+	queue *QueueOptions
+	// row lock clause
+	lock string
+	// tracked relations, order and pagination of query
+	tracked collectionQuery
 }
 
 // Items Get all items
@@ -63,36 +81,69 @@ func (c *Collection[T]) Item() *T {
 func (c *Collection[T]) Clear() {
 	c.Iterator.Reset()
 	c.items = c.items[:0]
+	c.computed = nil
+}
+
+// checkTenant collection of multi-tenant models must be scoped by tenant
+func (c *Collection[T]) checkTenant() porterr.IError {
+	var item interface{} = new(T)
+	if model := item.(IModel); !c.scoped && IsTenantModel(model) {
+		return errorNoTenant(model)
+	}
+	return nil
 }
 
 // fetch collection data private method
 func (c *Collection[T]) preload(q godb.Queryer) (rows *sql.Rows, e porterr.IError) {
+	if e = c.checkTenant(); e != nil {
+		return
+	}
+	query, e := c.query()
+	if e != nil {
+		return
+	}
 	var err error
-	rows, err = q.Query(c.String(), c.GetArguments()...)
+	rows, err = q.Query(query, c.GetArguments()...)
 	if err != nil {
-		e = porterr.New(porterr.PortErrorDatabaseQuery, "Collection search query error: "+err.Error())
+		if isLockError(err) {
+			e = NewDatabaseError(err)
+		} else {
+			e = porterr.New(porterr.PortErrorDatabaseQuery, "Collection search query error: "+err.Error())
+		}
 	}
 	return
 }
 
-// scan collection method
+// scan collection method. Result columns are bound to model fields by column name
 func (c *Collection[T]) scan(rows *sql.Rows) (e porterr.IError) {
 	if rows == nil {
 		return
 	}
-	// row values
-	var values []interface{}
+	columns, err := rows.Columns()
+	if err != nil {
+		return porterr.New(porterr.PortErrorIO, "Collection columns error: "+err.Error())
+	}
+	var binding *scanBinding
 	for rows.Next() {
 		var model interface{} = new(T)
-		values = (model).(IModel).Values()
-		if c.CountOver >= 0 {
-			values = append(values, &c.CountOver)
+		if binding == nil {
+			binding = newScanBinding(model.(IModel), columns, c.CountOver >= 0)
 		}
-		err := rows.Scan(values...)
+		values := binding.destinations(model.(IModel), &c.CountOver)
+		err = rows.Scan(values...)
 		if err != nil {
 			e = porterr.New(porterr.PortErrorIO, (model).(IModel).Table()+" model scan error: "+err.Error())
 			return
 		}
+		if e = decryptModel(model.(IModel)); e != nil {
+			return
+		}
+		if computed := binding.computed(values); computed != nil {
+			if c.computed == nil {
+				c.computed = make(map[*T]map[string]any)
+			}
+			c.computed[model.(*T)] = computed
+		}
 		c.AddItem(model.(*T))
 	}
 	return
@@ -115,14 +166,28 @@ func (c *Collection[T]) RemoveCountOver() {
 }
 
 // Load collection
-func (c *Collection[T]) Load(q godb.Queryer) porterr.IError {
-	rows, e := c.preload(q)
+func (c *Collection[T]) Load(q godb.Queryer) (e porterr.IError) {
+	defer c.observe(IndexOperationLoad, time.Now(), &e)
+	var rows *sql.Rows
+	rows, e = c.preload(q)
 	if e != nil {
-		return e
+		return
 	}
 	defer func() { _ = rows.Close() }()
 	c.Clear()
-	return c.scan(rows)
+	e = c.scan(rows)
+	if e == nil {
+		c.paginate()
+	}
+	return
+}
+
+// observe collection operation metrics
+func (c *Collection[T]) observe(io IndexOperation, start time.Time, e *porterr.IError) {
+	var item interface{} = new(T)
+	if model, ok := item.(IModel); ok {
+		Metrics.Observe(model.Table(), io, start, *e)
+	}
 }
 
 // Map collection
@@ -151,7 +216,14 @@ func (c *Collection[T]) Filter(callback func(*T) bool) {
 }
 
 // Save Create or Update collection items
-func (c *Collection[T]) Save(q godb.Queryer) (e porterr.IError) {
+func (c *Collection[T]) Save(q godb.Queryer) porterr.IError {
+	return c.SaveContext(context.Background(), q)
+}
+
+// SaveContext Create or Update collection items
+// ctx - context with actor for audit and tenant for multi-tenant models
+func (c *Collection[T]) SaveContext(ctx context.Context, q godb.Queryer) (e porterr.IError) {
+	defer c.observe(IndexOperationSave, time.Now(), &e)
 	var m interface{} = new(T)
 	if _, ok := m.(IModel); !ok {
 		e = porterr.New(porterr.PortErrorArgument, "Type T is not implements IModel interface")
@@ -163,52 +235,106 @@ func (c *Collection[T]) Save(q godb.Queryer) (e porterr.IError) {
 			_ = stmts[s].Close()
 		}
 	}()
-	var err error
 	for c.Next() {
-		query, params, returning := GetSaveSQL((interface{})(c.Item()).(IModel)).SQL()
-		if _, ok := stmts[query]; !ok {
-			stmts[query], err = q.Prepare(query)
+		model := (interface{})(c.Item()).(IModel)
+		if e = applyTenant(ctx, model); e != nil {
+			return
+		}
+		e = Audit.track(ctx, q, model, IndexOperationSave, func() porterr.IError {
+			isql, e := getSaveSQL(IndexCache, model)
+			if e != nil {
+				return e
+			}
+			var err error
+			query, params, returning := isql.SQL()
+			params = bindClock(q, params)
+			if _, ok := stmts[query]; !ok {
+				stmts[query], err = q.Prepare(query)
+				if err != nil {
+					return NewDatabaseError(err)
+				}
+			}
+			err = stmts[query].QueryRow(params...).Scan(returning...)
 			if err != nil {
-				return porterr.New(porterr.PortErrorIO, err.Error())
+				return NewDatabaseError(err)
 			}
-		}
-		err = stmts[query].QueryRow(params...).Scan(returning...)
-		if err != nil {
-			return porterr.New(porterr.PortErrorIO, err.Error())
+			return Outbox.emit(q, model, IndexOperationSave)
+		})
+		if e != nil {
+			return
 		}
 	}
 	return
 }
 
 // Delete delete items in collection
-func (c *Collection[T]) Delete(q godb.Queryer) (e porterr.IError) {
+func (c *Collection[T]) Delete(q godb.Queryer) porterr.IError {
+	return c.DeleteContext(context.Background(), q)
+}
+
+// DeleteContext delete items in collection
+// Items with single primary key are deleted with one query if model is not audited and has no outbox events
+// Dependent rows registered in Cascade are soft deleted in the same transaction
+// ctx - context with actor for audit and tenant for multi-tenant models
+func (c *Collection[T]) DeleteContext(ctx context.Context, q godb.Queryer) (e porterr.IError) {
+	defer c.observe(IndexOperationDelete, time.Now(), &e)
 	var m interface{} = new(T)
 	if _, ok := m.(IModel); !ok {
 		e = porterr.New(porterr.PortErrorArgument, "Type T is not implements IModel interface")
 		return
 	}
+	models := make([]IModel, len(c.items))
+	for i := range c.items {
+		models[i] = (interface{})(c.items[i]).(IModel)
+	}
+	e = Cascade.runItems(q, models, func(q godb.Queryer) porterr.IError {
+		if c.isBulkDelete() {
+			return c.deleteItems(ctx, q)
+		}
+		return c.deleteEach(ctx, q)
+	})
+	return
+}
+
+// deleteEach delete collection items one by one
+func (c *Collection[T]) deleteEach(ctx context.Context, q godb.Queryer) (e porterr.IError) {
 	var stmts = make(map[string]*godb.SqlStmt)
 	defer func() {
 		for s := range stmts {
 			_ = stmts[s].Close()
 		}
 	}()
-	var err error
 	for c.Next() {
-		query, params, returning := GetDeleteSQL((interface{})(c.Item()).(IModel)).SQL()
-		if _, ok := stmts[query]; !ok {
-			stmts[query], err = q.Prepare(query)
+		model := (interface{})(c.Item()).(IModel)
+		if e = applyTenant(ctx, model); e != nil {
+			return
+		}
+		e = Audit.track(ctx, q, model, IndexOperationDelete, func() porterr.IError {
+			isql := GetDeleteSQL(model)
+			if isql == nil {
+				return errorEmptySQL()
+			}
+			var err error
+			query, params, returning := isql.SQL()
+			params = bindClock(q, params)
+			if _, ok := stmts[query]; !ok {
+				stmts[query], err = q.Prepare(query)
+				if err != nil {
+					return NewDatabaseError(err)
+				}
+			}
+			if len(returning) > 0 {
+				err = stmts[query].QueryRow(params...).Scan(returning...)
+			} else {
+				_, err = stmts[query].Exec(params...)
+			}
 			if err != nil {
-				return porterr.New(porterr.PortErrorIO, err.Error())
+				return NewDatabaseError(err)
 			}
-		}
-		if len(returning) > 0 {
-			err = stmts[query].QueryRow(params...).Scan(returning...)
-		} else {
-			_, err = stmts[query].Exec(params...)
-		}
-		if err != nil {
-			return porterr.New(porterr.PortErrorIO, err.Error())
+			return Outbox.emit(q, model, IndexOperationDelete)
+		})
+		if e != nil {
+			return
 		}
 	}
 	return
@@ -232,3 +358,24 @@ func NewCollection[T any]() *Collection[T] {
 	}
 	return collection
 }
+
+// NewCollectionContext Create new model collection scoped by tenant from context
+// For multi-tenant models returns nil if context has no tenant
+func NewCollectionContext[T any](ctx context.Context) *Collection[T] {
+	collection := NewCollection[T]()
+	if collection == nil {
+		return nil
+	}
+	var item interface{} = new(T)
+	model := item.(IModel)
+	if IsTenantModel(model) {
+		tenant, ok := TenantFromContext(ctx)
+		if !ok {
+			return nil
+		}
+		column := PrepareMetaModel(model).Fields.Tenant().Column
+		collection.Where().AddExpression(model.Table()+"."+column+" = ?", tenant)
+	}
+	collection.scoped = true
+	return collection
+}
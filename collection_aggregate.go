@@ -0,0 +1,251 @@
+package gomodel
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+)
+
+const (
+	// AggregateSum sum of values
+	AggregateSum = "SUM"
+	// AggregateAvg average of values
+	AggregateAvg = "AVG"
+	// AggregateMin minimal value
+	AggregateMin = "MIN"
+	// AggregateMax maximal value
+	AggregateMax = "MAX"
+	// AggregateCount count of rows or not null values
+	AggregateCount = "COUNT"
+)
+
+// AggregateExpr aggregate expression of model field
+// Expression without function is a group by column
+type AggregateExpr struct {
+	// Aggregate function
+	Function string
+	// Pointer to model field. Nil for COUNT(*)
+	Field any
+	// Aggregate distinct values
+	Distinct bool
+	// Result column name. Column of field for group by, lower case function name by default
+	Alias string
+}
+
+// AggregateRow result row of aggregate query by result column name
+type AggregateRow map[string]any
+
+// Float64 value of column as float64. Zero for null
+func (r AggregateRow) Float64(column string) float64 {
+	switch v := r[column].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case []byte:
+		f, _ := strconv.ParseFloat(string(v), 64)
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// Int64 value of column as int64. Zero for null
+func (r AggregateRow) Int64(column string) int64 {
+	switch v := r[column].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case []byte:
+		i, _ := strconv.ParseInt(string(v), 10, 64)
+		return i
+	case string:
+		i, _ := strconv.ParseInt(v, 10, 64)
+		return i
+	}
+	return 0
+}
+
+// String value of column as string. Empty for null
+func (r AggregateRow) String(column string) string {
+	switch v := r[column].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// aggregateColumn qualified column of model field
+func (c *Collection[T]) aggregateColumn(field any) (string, porterr.IError) {
+	pos := c.fieldPosition(field)
+	if pos < 0 {
+		return "", porterr.New(porterr.PortErrorArgument, "Field is not found in model "+(interface{})(c.Model()).(IModel).Table())
+	}
+	var item interface{} = c.Model()
+	return item.(IModel).Table() + "." + item.(IModel).Columns()[pos], nil
+}
+
+// getAggregateSQL aggregate query with collection conditions and relations
+// Order and pagination of collection are not used
+func (c *Collection[T]) getAggregateSQL(exprs ...AggregateExpr) (*gosql.Select, []string, porterr.IError) {
+	if e := c.checkTenant(); e != nil {
+		return nil, nil, e
+	}
+	if len(exprs) == 0 {
+		return nil, nil, porterr.New(porterr.PortErrorArgument, "Aggregate expressions are empty")
+	}
+	if !c.isRebuilt(false) {
+		return nil, nil, errorNotRebuilt()
+	}
+	query := c.derivedSelect()
+	aliases := make([]string, len(exprs))
+	for i, expr := range exprs {
+		column := "*"
+		if expr.Field != nil {
+			var e porterr.IError
+			if column, e = c.aggregateColumn(expr.Field); e != nil {
+				return nil, nil, e
+			}
+		}
+		aliases[i] = expr.Alias
+		if expr.Function == "" {
+			if expr.Field == nil {
+				return nil, nil, porterr.New(porterr.PortErrorArgument, "Group by field is not set")
+			}
+			if aliases[i] == "" {
+				aliases[i] = (interface{})(c.Model()).(IModel).Columns()[c.fieldPosition(expr.Field)]
+			}
+			query.GroupBy(column)
+			query.Columns().Add(column + " AS " + aliases[i])
+			continue
+		}
+		if aliases[i] == "" {
+			aliases[i] = strings.ToLower(expr.Function)
+		}
+		if expr.Distinct {
+			column = "DISTINCT " + column
+		}
+		query.Columns().Add(expr.Function + "(" + column + ") AS " + aliases[i])
+	}
+	return query, aliases, nil
+}
+
+// Aggregate query aggregate expressions with collection conditions
+// Example: c.Aggregate(q, AggregateExpr{Field: &m.Status}, AggregateExpr{Function: AggregateSum, Field: &m.Amount, Alias: "total"})
+func (c *Collection[T]) Aggregate(q godb.Queryer, exprs ...AggregateExpr) (result []AggregateRow, e porterr.IError) {
+	defer c.observe(IndexOperationLoad, time.Now(), &e)
+	query, aliases, e := c.getAggregateSQL(exprs...)
+	if e != nil {
+		return
+	}
+	rows, err := q.Query(query.String(), query.GetArguments()...)
+	if err != nil {
+		return nil, NewDatabaseError(err)
+	}
+	defer func() { _ = rows.Close() }()
+	values := make([]any, len(aliases))
+	for rows.Next() {
+		for i := range values {
+			values[i] = new(any)
+		}
+		if err = rows.Scan(values...); err != nil {
+			return nil, porterr.New(porterr.PortErrorIO, "Aggregate scan error: "+err.Error())
+		}
+		row := make(AggregateRow, len(aliases))
+		for i := range aliases {
+			row[aliases[i]] = *(values[i].(*any))
+		}
+		result = append(result, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, NewDatabaseError(err)
+	}
+	return
+}
+
+// aggregate query single aggregate value
+func (c *Collection[T]) aggregate(q godb.Queryer, expr AggregateExpr, dest any) (e porterr.IError) {
+	defer c.observe(IndexOperationLoad, time.Now(), &e)
+	query, _, e := c.getAggregateSQL(expr)
+	if e != nil {
+		return
+	}
+	if err := q.QueryRow(query.String(), query.GetArguments()...).Scan(dest); err != nil {
+		return NewDatabaseError(err)
+	}
+	return
+}
+
+// AggregateValue query single aggregate value with collection conditions into value of type V
+// Use field type for MIN and MAX, string or decimal type implementing sql.Scanner for exact SUM and AVG of numeric
+// Use pointer type V if result can be null, for example SUM or MAX when there are no rows
+// Example: total, e := AggregateValue[*string](q, c, AggregateExpr{Function: AggregateSum, Field: &m.Amount})
+func AggregateValue[V any, T any](q godb.Queryer, c *Collection[T], expr AggregateExpr) (result V, e porterr.IError) {
+	e = c.aggregate(q, expr, &result)
+	return
+}
+
+// Sum of field values as float64. Zero if there are no rows
+// Use AggregateValue for exact sum of numeric
+func (c *Collection[T]) Sum(q godb.Queryer, field any) (float64, porterr.IError) {
+	var result sql.NullFloat64
+	e := c.aggregate(q, AggregateExpr{Function: AggregateSum, Field: field}, &result)
+	return result.Float64, e
+}
+
+// Avg average of field values as float64. Zero if there are no rows
+// Use AggregateValue for exact average of numeric
+func (c *Collection[T]) Avg(q godb.Queryer, field any) (float64, porterr.IError) {
+	var result sql.NullFloat64
+	e := c.aggregate(q, AggregateExpr{Function: AggregateAvg, Field: field}, &result)
+	return result.Float64, e
+}
+
+// CountDistinct count of distinct not null field values
+func (c *Collection[T]) CountDistinct(q godb.Queryer, field any) (int64, porterr.IError) {
+	var result int64
+	e := c.aggregate(q, AggregateExpr{Function: AggregateCount, Field: field, Distinct: true}, &result)
+	return result, e
+}
+
+// Min minimal field value. Result has type of field, for example *time.Time
+// Use AggregateValue for typed result
+func (c *Collection[T]) Min(q godb.Queryer, field any) (any, porterr.IError) {
+	return c.extremum(q, AggregateMin, field)
+}
+
+// Max maximal field value. Result has type of field, for example *time.Time
+// Use AggregateValue for typed result
+func (c *Collection[T]) Max(q godb.Queryer, field any) (any, porterr.IError) {
+	return c.extremum(q, AggregateMax, field)
+}
+
+// extremum query min or max value into value of field type
+func (c *Collection[T]) extremum(q godb.Queryer, function string, field any) (any, porterr.IError) {
+	v := reflect.ValueOf(field)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, porterr.New(porterr.PortErrorArgument, "Field must be a pointer to model field")
+	}
+	result := reflect.New(v.Type().Elem())
+	if e := c.aggregate(q, AggregateExpr{Function: function, Field: field}, result.Interface()); e != nil {
+		return nil, e
+	}
+	return result.Elem().Interface(), nil
+}
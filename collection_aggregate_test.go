@@ -0,0 +1,99 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestCollection_getAggregateSQL(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	m := c.Model()
+	c.Where().AddExpression("some_int > ?", 10)
+	c.Relate("JOIN author a ON a.id = test_model_1.some_int")
+	c.AddOrder("id DESC")
+	c.SetPagination(10, 20)
+	query, aliases, e := c.getAggregateSQL(
+		AggregateExpr{Field: &m.Name},
+		AggregateExpr{Function: AggregateSum, Field: &m.SomeInt, Alias: "total"},
+		AggregateExpr{Function: AggregateCount, Field: &m.Id, Distinct: true},
+		AggregateExpr{Function: AggregateCount, Alias: "rows"},
+	)
+	if e != nil {
+		t.Fatal(e)
+	}
+	expected := "SELECT test_model_1.name AS name, SUM(test_model_1.some_int) AS total, COUNT(DISTINCT test_model_1.id) AS count, COUNT(*) AS rows " +
+		"FROM test_model_1 JOIN author a ON a.id = test_model_1.some_int WHERE ((some_int > ?)) GROUP BY test_model_1.name"
+	if query.String() != expected {
+		t.Fatal("wrong aggregate query: " + query.String())
+	}
+	if len(aliases) != 4 || aliases[0] != "name" || aliases[3] != "rows" || len(query.GetArguments()) != 1 {
+		t.Fatal("wrong aliases")
+	}
+	if c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 JOIN author a ON a.id = test_model_1.some_int WHERE (some_int > ?) ORDER BY id DESC LIMIT 10 OFFSET 20" {
+		t.Fatal("collection query must not be changed: " + c.String())
+	}
+	c.Where().AddExpression("id > ?", 1)
+	query.Where().AddExpression("name IS NOT NULL")
+	if len(query.GetArguments()) != 1 || c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 JOIN author a ON a.id = test_model_1.some_int WHERE (some_int > ? AND id > ?) ORDER BY id DESC LIMIT 10 OFFSET 20" {
+		t.Fatal("aggregate query must not share conditions with collection: " + c.String())
+	}
+	if _, _, e = c.getAggregateSQL(AggregateExpr{Function: AggregateSum, Field: new(int)}); e == nil {
+		t.Fatal("unknown field must be error")
+	}
+	if _, _, e = c.getAggregateSQL(); e == nil {
+		t.Fatal("empty expressions must be error")
+	}
+	union := NewCollection[InsertModel1]()
+	union.Relate("JOIN author a ON a.id = test_model_1.some_int").SetPagination(10, 0)
+	if _, _, e = union.getAggregateSQL(AggregateExpr{Function: AggregateCount}); e == nil {
+		t.Fatal("not tracked clauses must be rejected")
+	}
+	if _, _, e = NewCollection[TenantModel]().getAggregateSQL(AggregateExpr{Function: AggregateCount}); e == nil {
+		t.Fatal("not scoped tenant collection must be rejected")
+	}
+}
+
+func TestAggregateRow(t *testing.T) {
+	row := AggregateRow{"sum": []byte("10.5"), "count": int64(3), "name": "foo"}
+	if row.Float64("sum") != 10.5 || row.Int64("count") != 3 || row.Float64("count") != 3 || row.String("name") != "foo" {
+		t.Fatal("wrong typed values")
+	}
+	if row.Float64("none") != 0 || row.String("count") != "3" || row.String("none") != "" {
+		t.Fatal("wrong default values")
+	}
+}
+
+func TestAggregateValue(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	var queries []string
+	results := []driver.Value{[]byte("12345678901234567.89"), now, nil, int64(7)}
+	dbo, _ := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		queries = append(queries, query)
+		value := results[0]
+		results = results[1:]
+		return fakeResult{columns: []string{"value"}, rows: [][]driver.Value{{value}}}
+	})
+	c := NewCollection[InsertModel1]()
+	m := c.Model()
+	c.Where().AddExpression("id > ?", 1)
+	sum, e := AggregateValue[*string](dbo, c, AggregateExpr{Function: AggregateSum, Field: &m.SomeInt})
+	if e != nil || sum == nil || *sum != "12345678901234567.89" {
+		t.Fatal("sum must be exact")
+	}
+	last, e := AggregateValue[*time.Time](dbo, c, AggregateExpr{Function: AggregateMax, Field: &m.CreatedAt})
+	if e != nil || last == nil || !last.Equal(now) {
+		t.Fatal("max must be typed")
+	}
+	first, e := AggregateValue[*time.Time](dbo, c, AggregateExpr{Function: AggregateMin, Field: &m.CreatedAt})
+	if e != nil || first != nil {
+		t.Fatal("null must be nil")
+	}
+	count, e := AggregateValue[int](dbo, c, AggregateExpr{Function: AggregateCount})
+	if e != nil || count != 7 {
+		t.Fatal("count must be typed")
+	}
+	if queries[0] != "SELECT SUM(test_model_1.some_int) AS sum FROM test_model_1 WHERE ((id > ?))" || queries[1] != "SELECT MAX(test_model_1.created_at) AS max FROM test_model_1 WHERE ((id > ?))" {
+		t.Fatal("wrong aggregate queries: " + queries[0])
+	}
+}
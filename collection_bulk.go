@@ -0,0 +1,255 @@
+package gomodel
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// whereCondition copy of collection conditions for bulk operations
+// Collection without conditions or with other clauses like relations or pagination is not allowed for bulk operations
+// Audited models and models with outbox events are changed one by one only
+func (c *Collection[T]) whereCondition() (*gosql.Condition, porterr.IError) {
+	if e := c.checkTenant(); e != nil {
+		return nil, e
+	}
+	var item interface{} = c.Model()
+	model := item.(IModel)
+	_, isSource := model.(EventSource)
+	if Audit.IsAudited(model) || Outbox.IsRegistered(model) || isSource {
+		return nil, porterr.New(porterr.PortErrorArgument, "Model "+model.Table()+" is audited or has outbox events. Bulk operation is not allowed")
+	}
+	if c.Where().IsEmpty() {
+		return nil, porterr.New(porterr.PortErrorArgument, "Collection conditions are empty. Bulk operation is not allowed")
+	}
+	if !c.isRebuilt(true) {
+		return nil, porterr.New(porterr.PortErrorArgument, "Collection has relations, pagination or other clauses. Bulk operation is not allowed")
+	}
+	return copyCondition(c.Where()), nil
+}
+
+// getUpdateWhereSQL bulk update query
+func (c *Collection[T]) getUpdateWhereSQL(set map[any]any) (*gosql.Update, porterr.IError) {
+	cond, e := c.whereCondition()
+	if e != nil {
+		return nil, e
+	}
+	if len(set) == 0 {
+		return nil, porterr.New(porterr.PortErrorArgument, "Nothing to update")
+	}
+	var item interface{} = c.Model()
+	meta := PrepareMetaModel(item.(IModel))
+	var positions = make([]int, 0, len(set))
+	var values = make(map[int]any, len(set))
+	for field, value := range set {
+		pos := c.fieldPosition(field)
+		if pos < 0 {
+			return nil, porterr.New(porterr.PortErrorArgument, "Field is not found in model "+meta.TableName)
+		}
+		positions = append(positions, pos)
+		values[pos] = value
+	}
+	sort.Ints(positions)
+	update := gosql.NewUpdate().Table(meta.TableName)
+	for _, pos := range positions {
+		value := values[pos]
+		if meta.Fields[pos].IsEncrypted {
+			value = encryptedValue{value: value}
+		} else if meta.Fields[pos].IsArray {
+			value = pq.Array(value)
+		}
+		update.Set().Append(meta.Fields[pos].Column+" = ?", value)
+	}
+	for i := range meta.Fields {
+		if meta.Fields[i].IsUpdatedAt && values[i] == nil {
+			expr, args := setNow(meta.Fields[i].Column)
+			update.Set().Append(expr, args...)
+		}
+	}
+	update.Where().Replace(cond)
+	return update, nil
+}
+
+// UpdateWhere update rows matched by collection conditions with one query
+// set - values by pointer to field of Model()
+// Returns count of updated rows
+func (c *Collection[T]) UpdateWhere(q godb.Queryer, set map[any]any) (count int64, e porterr.IError) {
+	defer c.observe(IndexOperationUpdate, time.Now(), &e)
+	var update *gosql.Update
+	update, e = c.getUpdateWhereSQL(set)
+	if e != nil {
+		return
+	}
+	return execCount(q, update)
+}
+
+// getDeleteWhereSQL bulk delete query. Soft delete for models with deleted at field
+func (c *Collection[T]) getDeleteWhereSQL() (gosql.ISQL, porterr.IError) {
+	cond, e := c.whereCondition()
+	if e != nil {
+		return nil, e
+	}
+	var item interface{} = c.Model()
+	meta := PrepareMetaModel(item.(IModel))
+	if !meta.Fields.IsSoft() {
+		del := gosql.NewDelete().From(meta.TableName)
+		del.Where().Replace(cond)
+		return del, nil
+	}
+	update := gosql.NewUpdate().Table(meta.TableName)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsUpdatedAt || meta.Fields[i].IsDeletedAt {
+			expr, args := setNow(meta.Fields[i].Column)
+			update.Set().Append(expr, args...)
+		}
+	}
+	update.Where().Replace(cond)
+	return update, nil
+}
+
+// DeleteWhere delete rows matched by collection conditions with one query
+// Rows are soft deleted if model has deleted at field
+// Dependent rows registered in Cascade are soft deleted in the same transaction
+// Returns count of deleted rows
+func (c *Collection[T]) DeleteWhere(q godb.Queryer) (count int64, e porterr.IError) {
+	defer c.observe(IndexOperationDelete, time.Now(), &e)
+	var isql gosql.ISQL
+	isql, e = c.getDeleteWhereSQL()
+	if e != nil {
+		return
+	}
+	if update, ok := isql.(*gosql.Update); ok {
+		var item interface{} = c.Model()
+		return Cascade.runWhere(q, item.(IModel).Table(), update)
+	}
+	return execCount(q, isql)
+}
+
+// execCount exec query and get count of affected rows
+func execCount(q godb.Queryer, isql gosql.ISQL) (count int64, e porterr.IError) {
+	query, params, _ := isql.SQL()
+	result, err := q.Exec(query, bindClock(q, params)...)
+	if err != nil {
+		return 0, NewDatabaseError(err)
+	}
+	count, err = result.RowsAffected()
+	if err != nil {
+		return 0, NewDatabaseError(err)
+	}
+	return
+}
+
+// isBulkDelete check if items can be deleted with one query
+// Model must have one primary key column and must not be audited or produce outbox events
+func (c *Collection[T]) isBulkDelete() bool {
+	var item interface{} = c.Model()
+	model := item.(IModel)
+	if Audit.IsAudited(model) || Outbox.IsRegistered(model) {
+		return false
+	}
+	if _, ok := model.(EventSource); ok {
+		return false
+	}
+	return len(PrepareMetaModel(model).Fields.Keys()) == 1
+}
+
+// getDeleteItemsSQL delete query for collection items by primary key
+// Returning primary key, updated at and deleted at for soft delete
+func (c *Collection[T]) getDeleteItemsSQL(ctx context.Context) (query string, params []any, returning []int, e porterr.IError) {
+	var item interface{} = c.Model()
+	meta := PrepareMetaModel(item.(IModel))
+	key := meta.Fields.Keys()[0]
+	pos := columnPosition(meta.Fields, key.Column)
+	keys := make([]any, 0, len(c.items))
+	var tenant any
+	for _, i := range c.items {
+		model := (interface{})(i).(IModel)
+		if e = applyTenant(ctx, model); e != nil {
+			return
+		}
+		if value, ok := relationKey(model.Values()[pos]); ok {
+			keys = append(keys, value)
+		}
+		if tpos := tenantPosition(model); tpos >= 0 {
+			tenant = model.Values()[tpos]
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	cond := gosql.NewSqlCondition(gosql.ConditionOperatorAnd)
+	cond.AddExpression(key.Column+" = ANY(?)", pq.Array(keys))
+	if t := meta.Fields.Tenant(); t != nil {
+		cond.AddExpression(t.Column+" = ?", tenant)
+	}
+	if !meta.Fields.IsSoft() {
+		del := gosql.NewDelete().From(meta.TableName)
+		del.Where().Replace(cond)
+		query, params, _ = del.SQL()
+		return
+	}
+	update := gosql.NewUpdate().Table(meta.TableName)
+	update.Returning().Append(key.Column)
+	returning = append(returning, pos)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsUpdatedAt || meta.Fields[i].IsDeletedAt {
+			expr, args := setNow(meta.Fields[i].Column)
+			update.Set().Append(expr, args...)
+			update.Returning().Append(meta.Fields[i].Column)
+			returning = append(returning, i)
+		}
+	}
+	update.Where().Replace(cond)
+	query, params, _ = update.SQL()
+	return
+}
+
+// deleteItems delete collection items with one query
+func (c *Collection[T]) deleteItems(ctx context.Context, q godb.Queryer) porterr.IError {
+	query, params, returning, e := c.getDeleteItemsSQL(ctx)
+	if e != nil || query == "" {
+		return e
+	}
+	params = bindClock(q, params)
+	if len(returning) == 0 {
+		_, err := q.Exec(query, params...)
+		if err != nil {
+			return NewDatabaseError(err)
+		}
+		return nil
+	}
+	rows, err := q.Query(query, params...)
+	if err != nil {
+		return NewDatabaseError(err)
+	}
+	defer func() { _ = rows.Close() }()
+	index := c.IndexBy()
+	for rows.Next() {
+		var model interface{} = new(T)
+		values := model.(IModel).Values()
+		dest := make([]any, len(returning))
+		for i, pos := range returning {
+			dest[i] = values[pos]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return NewDatabaseError(err)
+		}
+		key, _ := relationKey(values[returning[0]])
+		if target, ok := index[key]; ok {
+			targetValues := (interface{})(target).(IModel).Values()
+			for _, pos := range returning[1:] {
+				reflect.ValueOf(targetValues[pos]).Elem().Set(reflect.ValueOf(values[pos]).Elem())
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return NewDatabaseError(err)
+	}
+	return nil
+}
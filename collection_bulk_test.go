@@ -0,0 +1,161 @@
+package gomodel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dimonrus/gosql"
+)
+
+func TestCollection_UpdateWhere(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	m := c.Model()
+	if _, e := c.getUpdateWhereSQL(map[any]any{&m.Name: "foo"}); e == nil {
+		t.Fatal("update without conditions must be rejected")
+	}
+	c.Where().AddExpression("some_int > ?", 10)
+	if _, e := c.getUpdateWhereSQL(nil); e == nil {
+		t.Fatal("empty set must be rejected")
+	}
+	if _, e := c.getUpdateWhereSQL(map[any]any{new(int): 1}); e == nil {
+		t.Fatal("unknown field must be rejected")
+	}
+	update, e := c.getUpdateWhereSQL(map[any]any{&m.SomeInt: 5, &m.Name: "foo"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	query, params, _ := update.SQL()
+	if query != "UPDATE test_model_1 SET name = ?, some_int = ?, updated_at = NOW() WHERE ((some_int > ?));" {
+		t.Fatal("wrong update query: " + query)
+	}
+	if len(params) != 3 || params[0] != "foo" || params[2] != 10 {
+		t.Fatal("wrong update params")
+	}
+	if c.Where().String() != "(some_int > ?)" {
+		t.Fatal("collection conditions must not be changed")
+	}
+}
+
+func TestCollection_DeleteWhere(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	c.Where().AddExpression("name = ?", "foo")
+	isql, e := c.getDeleteWhereSQL()
+	if e != nil {
+		t.Fatal(e)
+	}
+	query, params, _ := isql.SQL()
+	if query != "UPDATE test_model_1 SET updated_at = NOW(), deleted_at = NOW() WHERE ((name = ?));" || len(params) != 1 {
+		t.Fatal("wrong soft delete query: " + query)
+	}
+	d := NewCollection[DeleteModel1]()
+	d.Where().AddExpression("name = ?", "foo")
+	isql, e = d.getDeleteWhereSQL()
+	if e != nil {
+		t.Fatal(e)
+	}
+	query, _, _ = isql.SQL()
+	if query != "DELETE FROM test_model_del_1 WHERE ((name = ?));" {
+		t.Fatal("wrong delete query: " + query)
+	}
+	if _, e = NewCollection[TenantModel]().getDeleteWhereSQL(); e == nil {
+		t.Fatal("not scoped tenant collection must be rejected")
+	}
+	d.Relate("JOIN author a ON a.id = test_model_del_1.some_int")
+	if _, e = d.getDeleteWhereSQL(); e == nil {
+		t.Fatal("collection with relations must be rejected")
+	}
+	d.ResetRelations()
+	d.SetPagination(10, 0)
+	if _, e = d.getDeleteWhereSQL(); e == nil {
+		t.Fatal("collection with pagination must be rejected")
+	}
+	m := d.Model()
+	if _, e = d.getUpdateWhereSQL(map[any]any{&m.Name: "bar"}); e == nil {
+		t.Fatal("update of collection with pagination must be rejected")
+	}
+	d.SetPagination(0, 0)
+	if _, e = d.getDeleteWhereSQL(); e != nil {
+		t.Fatal(e)
+	}
+	clauses := map[string]func(c *Collection[DeleteModel1]){
+		"chained": func(c *Collection[DeleteModel1]) { c.Relate("JOIN author a ON a.id = some_int").SetPagination(10, 0) },
+		"select":  func(c *Collection[DeleteModel1]) { c.Select.SetPagination(10, 0) },
+		"from":    func(c *Collection[DeleteModel1]) { c.From("author") },
+		"with":    func(c *Collection[DeleteModel1]) { c.With().Add("a", gosql.NewSelect().From("author")) },
+		"union":   func(c *Collection[DeleteModel1]) { c.Union(gosql.NewSelect().From("author")) },
+		"order":   func(c *Collection[DeleteModel1]) { c.AddOrder("id") },
+		"keyset":  func(c *Collection[DeleteModel1]) { _ = c.Paginate("", 10) },
+		"no_where": func(c *Collection[DeleteModel1]) {
+			c.Where().Replace(gosql.NewSqlCondition(gosql.ConditionOperatorAnd))
+		},
+	}
+	for name, clause := range clauses {
+		c := NewCollection[DeleteModel1]()
+		c.Where().AddExpression("name = ?", "foo")
+		clause(c)
+		if _, e = c.getDeleteWhereSQL(); e == nil {
+			t.Fatal("collection with " + name + " clause must be rejected")
+		}
+	}
+	Audit.Register(&DeleteModel1{})
+	if _, e = d.getDeleteWhereSQL(); e == nil {
+		t.Fatal("audited model must be rejected")
+	}
+	Audit.Unregister(&DeleteModel1{})
+	Outbox.Register(&DeleteModel1{})
+	if _, e = d.getDeleteWhereSQL(); e == nil {
+		t.Fatal("outbox model must be rejected")
+	}
+	Outbox.Unregister(&DeleteModel1{})
+}
+
+func TestCollection_whereCondition(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	c.Where().AddExpression("name = ?", "foo")
+	c.Where().Merge(gosql.ConditionOperatorAnd, gosql.NewSqlCondition(gosql.ConditionOperatorOr).AddExpression("id = ?", 1))
+	cond, e := c.whereCondition()
+	if e != nil {
+		t.Fatal(e)
+	}
+	cond.AddExpression("some_int = ?", 2)
+	c.Where().AddExpression("pages IS NULL")
+	if cond.String() != "(((id = ?) AND (name = ?)) AND some_int = ?)" || len(cond.GetArguments()) != 3 {
+		t.Fatal("wrong copy of conditions: " + cond.String())
+	}
+	if c.Where().String() != "((id = ?) AND (name = ? AND pages IS NULL))" || len(c.Where().GetArguments()) != 2 {
+		t.Fatal("collection conditions must not be changed: " + c.Where().String())
+	}
+}
+
+func TestCollection_getDeleteItemsSQL(t *testing.T) {
+	c := NewCollection[DeleteModel1]()
+	if !c.isBulkDelete() || NewCollection[DeleteModel2]().isBulkDelete() {
+		t.Fatal("wrong bulk delete check")
+	}
+	query, _, _, e := c.getDeleteItemsSQL(context.Background())
+	if e != nil || query != "" {
+		t.Fatal("empty collection must be without query")
+	}
+	for i := 1; i <= 3; i++ {
+		id := i
+		c.AddItem(&DeleteModel1{Id: &id})
+	}
+	c.AddItem(&DeleteModel1{})
+	query, params, returning, e := c.getDeleteItemsSQL(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if query != "DELETE FROM test_model_del_1 WHERE (id = ANY(?));" || len(params) != 1 || len(returning) != 0 {
+		t.Fatal("wrong delete query: " + query)
+	}
+	s := NewCollection[InsertModel1]()
+	id := 1
+	s.AddItem(&InsertModel1{Id: &id})
+	query, _, returning, e = s.getDeleteItemsSQL(context.Background())
+	if e != nil {
+		t.Fatal(e)
+	}
+	if query != "UPDATE test_model_1 SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ANY(?)) RETURNING id, updated_at, deleted_at;" || len(returning) != 3 {
+		t.Fatal("wrong soft delete query: " + query)
+	}
+}
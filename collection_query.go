@@ -0,0 +1,98 @@
+package gomodel
+
+import (
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+)
+
+// collectionQuery relations, order and pagination of collection query
+// Select does not expose them, so they are tracked to build derived queries
+type collectionQuery struct {
+	// join relations
+	relations []string
+	// order expressions
+	orders []string
+	// limit of pagination
+	limit int
+	// offset of pagination
+	offset int
+}
+
+// Relate add join relation to collection query
+func (c *Collection[T]) Relate(relation ...string) *gosql.Select {
+	c.tracked.relations = append(c.tracked.relations, relation...)
+	return c.Select.Relate(relation...)
+}
+
+// ResetRelations remove join relations of collection query
+func (c *Collection[T]) ResetRelations() *gosql.Select {
+	c.tracked.relations = nil
+	return c.Select.ResetRelations()
+}
+
+// AddOrder add order expression to collection query
+func (c *Collection[T]) AddOrder(expression ...string) *gosql.Select {
+	c.tracked.orders = append(c.tracked.orders, expression...)
+	return c.Select.AddOrder(expression...)
+}
+
+// ResetOrder remove order of collection query
+func (c *Collection[T]) ResetOrder() *gosql.Select {
+	c.tracked.orders = nil
+	return c.Select.ResetOrder()
+}
+
+// SetPagination set limit and offset of collection query
+func (c *Collection[T]) SetPagination(limit int, offset int) *gosql.Select {
+	c.tracked.limit, c.tracked.offset = limit, offset
+	return c.Select.SetPagination(limit, offset)
+}
+
+// isRebuilt check if collection query is equal to query rebuilt from table, columns and conditions
+// whereOnly - rebuild without tracked relations, order and pagination
+// Clauses added to Select directly, like With, Union or extra From tables, make query different
+func (c *Collection[T]) isRebuilt(whereOnly bool) bool {
+	var item interface{} = c.Model()
+	query := gosql.NewSelect().From(item.(IModel).Table())
+	if c.Columns().Len() > 0 {
+		query.Columns().Add(c.Columns().String(", "))
+	}
+	if !whereOnly {
+		query.Relate(c.tracked.relations...)
+	}
+	if expr := c.Where().String(); len(expr) > 2 {
+		query.Where().AddExpression(expr[1 : len(expr)-1])
+	}
+	if !whereOnly {
+		query.AddOrder(c.tracked.orders...)
+		query.SetPagination(c.tracked.limit, c.tracked.offset)
+	}
+	return query.String() == c.String()
+}
+
+// errorNotRebuilt error of collection query with clauses that can not be copied to derived query
+func errorNotRebuilt() porterr.IError {
+	return porterr.New(porterr.PortErrorArgument, "Collection query has clauses that can not be copied. Use Relate, AddOrder and SetPagination of collection")
+}
+
+// copyCondition new condition with rendered expression and arguments of cond
+// Copy does not share expressions, arguments and merged conditions with cond
+func copyCondition(cond *gosql.Condition) *gosql.Condition {
+	copied := gosql.NewSqlCondition(gosql.ConditionOperatorAnd)
+	if !cond.IsEmpty() {
+		copied.AddExpression(cond.String(), cond.GetArguments()...)
+	}
+	return copied
+}
+
+// derivedSelect new query from collection table with relations and copy of conditions
+// Columns, order and pagination are not set. Collection query is not changed
+func (c *Collection[T]) derivedSelect() *gosql.Select {
+	var item interface{} = c.Model()
+	query := gosql.NewSelect().From(item.(IModel).Table())
+	if len(c.tracked.relations) > 0 {
+		query.Relate(append([]string(nil), c.tracked.relations...)...)
+	}
+	query.Where().Replace(copyCondition(c.Where()))
+	return query
+}
@@ -0,0 +1,227 @@
+package gomodel
+
+import (
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+// counter for cursor names
+var cursorCounter uint64
+
+// CollectionRows iterator over collection query rows
+// Rows are scanned one at a time into the same model
+type CollectionRows[T any] struct {
+	// collection with query
+	collection *Collection[T]
+	// queryer for cursor fetch
+	q godb.Queryer
+	// own transaction for server-side cursor
+	tx *godb.SqlTx
+	// current rows
+	rows *sql.Rows
+	// current item
+	item *T
+	// scan destinations of item
+	values []any
+	// binding of result columns
+	binding *scanBinding
+	// values of computed columns of current item
+	computed map[string]any
+	// server-side cursor name
+	cursor string
+	// count of rows in current fetch
+	fetched int
+	// iteration error
+	e porterr.IError
+	// is closed
+	closed bool
+}
+
+// Next scan next row. Returns false when rows are over or error occurred
+func (r *CollectionRows[T]) Next() bool {
+	if r.e != nil || r.closed {
+		return false
+	}
+	for {
+		if r.rows != nil {
+			if r.rows.Next() {
+				if r.binding == nil {
+					columns, err := r.rows.Columns()
+					if err != nil {
+						r.e = porterr.New(porterr.PortErrorIO, "Collection columns error: "+err.Error())
+						return false
+					}
+					r.binding = newScanBinding((interface{})(r.item).(IModel), columns, r.collection.CountOver >= 0)
+					r.values = r.binding.destinations((interface{})(r.item).(IModel), &r.collection.CountOver)
+				}
+				if err := r.rows.Scan(r.values...); err != nil {
+					r.e = porterr.New(porterr.PortErrorIO, (interface{})(r.item).(IModel).Table()+" model scan error: "+err.Error())
+					return false
+				}
+				if r.e = decryptModel((interface{})(r.item).(IModel)); r.e != nil {
+					return false
+				}
+				r.computed = r.binding.computed(r.values)
+				r.fetched++
+				return true
+			}
+			if err := r.rows.Err(); err != nil {
+				r.e = NewDatabaseError(err)
+				return false
+			}
+			_ = r.rows.Close()
+			r.rows = nil
+			if r.cursor == "" || r.fetched < r.collection.fetchSize {
+				return false
+			}
+		}
+		if r.cursor == "" {
+			return false
+		}
+		rows, err := r.q.Query("FETCH FORWARD " + strconv.Itoa(r.collection.fetchSize) + " FROM " + r.cursor)
+		if err != nil {
+			r.e = NewDatabaseError(err)
+			return false
+		}
+		r.rows = rows
+		r.fetched = 0
+	}
+}
+
+// Item current item. Item is reused for every row, copy it to keep
+func (r *CollectionRows[T]) Item() *T {
+	return r.item
+}
+
+// Computed values of computed columns of current item
+func (r *CollectionRows[T]) Computed() map[string]any {
+	return r.computed
+}
+
+// Err iteration error
+func (r *CollectionRows[T]) Err() porterr.IError {
+	return r.e
+}
+
+// Close rows, cursor and own transaction
+func (r *CollectionRows[T]) Close() (e porterr.IError) {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.rows != nil {
+		_ = r.rows.Close()
+	}
+	if r.tx != nil {
+		var err error
+		if r.e != nil {
+			err = r.tx.Rollback()
+		} else {
+			err = r.tx.Commit()
+		}
+		if err != nil {
+			e = NewDatabaseError(err)
+		}
+	} else if r.cursor != "" {
+		if _, err := r.q.Exec("CLOSE " + r.cursor); err != nil {
+			e = NewDatabaseError(err)
+		}
+	}
+	return
+}
+
+// WithCursor use server-side cursor with fetch size for Rows and Each
+// q for Rows and Each must be *godb.DBO, *Router or transaction
+func (c *Collection[T]) WithCursor(fetchSize int) *Collection[T] {
+	c.fetchSize = fetchSize
+	return c
+}
+
+// declareSQL query for server-side cursor
+func (c *Collection[T]) declareSQL(name string) (string, porterr.IError) {
+	query, e := c.query()
+	if e != nil {
+		return "", e
+	}
+	return "DECLARE " + name + " NO SCROLL CURSOR FOR " + query, nil
+}
+
+// Rows query collection rows for iteration without loading all items
+// Rows must be closed
+func (c *Collection[T]) Rows(q godb.Queryer) (*CollectionRows[T], porterr.IError) {
+	var item interface{} = new(T)
+	if _, ok := item.(IModel); !ok {
+		return nil, porterr.New(porterr.PortErrorArgument, "Type T is not implements IModel interface")
+	}
+	r := &CollectionRows[T]{collection: c, q: q, item: item.(*T)}
+	if c.fetchSize <= 0 {
+		rows, e := c.preload(q)
+		if e != nil {
+			return nil, e
+		}
+		r.rows = rows
+		return r, nil
+	}
+	if e := c.checkTenant(); e != nil {
+		return nil, e
+	}
+	var err error
+	switch db := q.(type) {
+	case *godb.DBO:
+		r.tx, err = db.Begin()
+	case *Router:
+		r.tx, err = db.Begin()
+	case *Tx, *godb.SqlTx:
+	default:
+		return nil, porterr.New(porterr.PortErrorArgument, "Queryer for cursor must be *godb.DBO, *Router or transaction")
+	}
+	if err != nil {
+		return nil, NewDatabaseError(err)
+	}
+	if r.tx != nil {
+		r.q = r.tx
+	}
+	r.cursor = "gomodel_cursor_" + strconv.FormatUint(atomic.AddUint64(&cursorCounter, 1), 10)
+	declare, e := c.declareSQL(r.cursor)
+	if e != nil {
+		r.e = e
+		_ = r.Close()
+		return nil, r.e
+	}
+	if _, err = r.q.Exec(declare, c.GetArguments()...); err != nil {
+		r.e = NewDatabaseError(err)
+		_ = r.Close()
+		return nil, r.e
+	}
+	return r, nil
+}
+
+// Each call callback for every row without loading all items
+// Iteration stops on first callback error. Item is reused for every row
+func (c *Collection[T]) Each(q godb.Queryer, callback func(item *T) error) (e porterr.IError) {
+	defer c.observe(IndexOperationLoad, time.Now(), &e)
+	var rows *CollectionRows[T]
+	rows, e = c.Rows(q)
+	if e != nil {
+		return
+	}
+	defer func() {
+		if ce := rows.Close(); e == nil {
+			e = ce
+		}
+	}()
+	for rows.Next() {
+		if err := callback(rows.Item()); err != nil {
+			if ie, ok := err.(porterr.IError); ok {
+				return ie
+			}
+			return porterr.New(porterr.PortErrorIO, "Collection callback error: "+err.Error())
+		}
+	}
+	return rows.Err()
+}
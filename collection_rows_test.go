@@ -0,0 +1,162 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+// fakeBooks database with book rows. FETCH returns next rows of cursor
+func fakeBooks(count int) (*godb.DBO, *fakeDB) {
+	var fetched int
+	return newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		result := fakeResult{columns: []string{"id", "author_id", "title"}}
+		switch {
+		case strings.HasPrefix(query, "SELECT"):
+			for i := 1; i <= count; i++ {
+				result.rows = append(result.rows, []driver.Value{int64(i), int64(1), "title"})
+			}
+		case strings.HasPrefix(query, "FETCH FORWARD 2"):
+			for i := 0; i < 2 && fetched < count; i++ {
+				fetched++
+				result.rows = append(result.rows, []driver.Value{int64(fetched), int64(1), "title"})
+			}
+		}
+		return result
+	})
+}
+
+func TestCollectionRows(t *testing.T) {
+	t.Run("declare", func(t *testing.T) {
+		c := NewCollection[InsertModel1]().WithCursor(100)
+		c.Where().AddExpression("id > ?", 10)
+		query, _ := c.declareSQL("gomodel_cursor_1")
+		t.Log(query)
+		if query != "DECLARE gomodel_cursor_1 NO SCROLL CURSOR FOR SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (id > ?)" {
+			t.Fatal("wrong declare query")
+		}
+	})
+	t.Run("cursor_queryer", func(t *testing.T) {
+		c := NewCollection[InsertModel1]().WithCursor(100)
+		if _, e := c.Rows(NewRouter(nil).WithContext(context.Background())); e == nil {
+			t.Fatal("must be queryer error")
+		}
+	})
+	t.Run("tenant", func(t *testing.T) {
+		if _, e := NewCollection[TenantModel]().WithCursor(100).Rows(nil); e == nil {
+			t.Fatal("not scoped collection must not be iterated")
+		}
+		e := NewCollection[TenantModel]().Each(nil, func(item *TenantModel) error { return nil })
+		if e == nil {
+			t.Fatal("not scoped collection must not be iterated")
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		rows := &CollectionRows[InsertModel1]{collection: NewCollection[InsertModel1](), item: &InsertModel1{}}
+		if rows.Next() || rows.Err() != nil {
+			t.Fatal("rows must be empty")
+		}
+		if rows.Close() != nil || rows.Close() != nil || rows.Next() {
+			t.Fatal("rows must be closed")
+		}
+	})
+	t.Run("rows", func(t *testing.T) {
+		dbo, fake := fakeBooks(3)
+		rows, e := NewCollection[RelationBook]().Rows(dbo)
+		if e != nil {
+			t.Fatal(e)
+		}
+		var ids []int
+		for rows.Next() {
+			ids = append(ids, *rows.Item().Id)
+		}
+		if rows.Err() != nil || rows.Close() != nil || len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+			t.Fatal("rows must be scanned to item")
+		}
+		if log := fake.log(); len(log) != 1 || log[0] != "SELECT id, author_id, title FROM book" {
+			t.Fatal("wrong queries: " + strings.Join(log, "\n"))
+		}
+	})
+	t.Run("each", func(t *testing.T) {
+		dbo, _ := fakeBooks(3)
+		var titles []string
+		e := NewCollection[RelationBook]().Each(dbo, func(item *RelationBook) error {
+			titles = append(titles, *item.Title)
+			return nil
+		})
+		if e != nil || len(titles) != 3 || titles[0] != "title" {
+			t.Fatal("each must be called for every row")
+		}
+	})
+	t.Run("each_error", func(t *testing.T) {
+		dbo, _ := fakeBooks(3)
+		var calls int
+		e := NewCollection[RelationBook]().Each(dbo, func(item *RelationBook) error {
+			calls++
+			if *item.Id == 2 {
+				return errors.New("stop")
+			}
+			return nil
+		})
+		if e == nil || e.Error() != "Collection callback error: stop" || calls != 2 {
+			t.Fatal("each must stop on callback error")
+		}
+		e = NewCollection[RelationBook]().Each(dbo, func(item *RelationBook) error {
+			return porterr.New(porterr.PortErrorConflict, "conflict")
+		})
+		if e == nil || e.GetCode() != porterr.PortErrorConflict {
+			t.Fatal("callback port error must be returned as is")
+		}
+	})
+	t.Run("cursor", func(t *testing.T) {
+		for _, count := range []int{5, 4} {
+			dbo, fake := fakeBooks(count)
+			rows, e := NewCollection[RelationBook]().WithCursor(2).Rows(dbo)
+			if e != nil {
+				t.Fatal(e)
+			}
+			var ids []int
+			for rows.Next() {
+				ids = append(ids, *rows.Item().Id)
+			}
+			if rows.Err() != nil || rows.Close() != nil || len(ids) != count || ids[count-1] != count {
+				t.Fatal("all batches must be fetched")
+			}
+			fetch := "FETCH FORWARD 2 FROM " + rows.cursor
+			expected := []string{"BEGIN", "DECLARE " + rows.cursor + " NO SCROLL CURSOR FOR SELECT id, author_id, title FROM book", fetch, fetch, fetch, "COMMIT"}
+			if log := fake.log(); strings.Join(log, "\n") != strings.Join(expected, "\n") {
+				t.Fatal("wrong queries: " + strings.Join(log, "\n"))
+			}
+		}
+	})
+	t.Run("cursor_tx", func(t *testing.T) {
+		dbo, fake := fakeBooks(3)
+		tx, err := dbo.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		e := NewCollection[RelationBook]().WithCursor(2).Each(tx, func(item *RelationBook) error {
+			ids = append(ids, *item.Id)
+			return nil
+		})
+		if e != nil || len(ids) != 3 {
+			t.Fatal("all batches must be fetched in transaction")
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		log := fake.log()
+		cursor := strings.Fields(log[1])[1]
+		fetch := "FETCH FORWARD 2 FROM " + cursor
+		expected := []string{"BEGIN", "DECLARE " + cursor + " NO SCROLL CURSOR FOR SELECT id, author_id, title FROM book", fetch, fetch, "CLOSE " + cursor, "COMMIT"}
+		if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+			t.Fatal("wrong queries: " + strings.Join(log, "\n"))
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package gomodel
+
+import (
+	"reflect"
+	"strings"
+)
+
+// scanBinding binding of result columns to model fields
+type scanBinding struct {
+	// result column names
+	columns []string
+	// position in model values. -1 if column is not a model column
+	values []int
+	// index of ignored struct field with column name. -1 if not found
+	fields []int
+	// position of count over column. -1 if not requested
+	count int
+}
+
+// newScanBinding bind result columns to model by column names
+// Columns without model field are computed columns
+// countOver - last column is COUNT(*) OVER()
+func newScanBinding(model IModel, columns []string, countOver bool) *scanBinding {
+	b := &scanBinding{
+		columns: columns,
+		values:  make([]int, len(columns)),
+		fields:  make([]int, len(columns)),
+		count:   -1,
+	}
+	if countOver && len(columns) > 0 {
+		b.count = len(columns) - 1
+	}
+	modelColumns := model.Columns()
+	var ignored map[string]int
+	for i := range columns {
+		b.values[i], b.fields[i] = -1, -1
+		if i == b.count {
+			continue
+		}
+		for j := range modelColumns {
+			if modelColumns[j] == columns[i] {
+				b.values[i] = j
+				break
+			}
+		}
+		if b.values[i] < 0 {
+			if ignored == nil {
+				ignored = ignoredFields(model)
+			}
+			if index, ok := ignored[columns[i]]; ok {
+				b.fields[i] = index
+			}
+		}
+	}
+	return b
+}
+
+// ignoredFields indexes of ignored struct fields by column name
+// Example: Score *float64 `db:"col~score;ign;"`
+func ignoredFields(model IModel) map[string]int {
+	te := reflect.TypeOf(model).Elem()
+	result := make(map[string]int)
+	for i := 0; i < te.NumField(); i++ {
+		if tag, ok := te.Field(i).Tag.Lookup("db"); ok && strings.Contains(tag, "ign") {
+			var field ModelFiledTag
+			ParseModelFiledTag(tag, &field)
+			if field.IsIgnored && field.Column != "" {
+				result[field.Column] = i
+			}
+		}
+	}
+	return result
+}
+
+// destinations scan destinations for model
+// Computed columns are scanned to *any
+func (b *scanBinding) destinations(model IModel, count *int) []any {
+	values := model.Values()
+	var ve reflect.Value
+	dest := make([]any, len(b.columns))
+	for i := range b.columns {
+		switch {
+		case i == b.count:
+			dest[i] = count
+		case b.values[i] >= 0:
+			dest[i] = values[b.values[i]]
+		case b.fields[i] >= 0:
+			if !ve.IsValid() {
+				ve = reflect.ValueOf(model).Elem()
+			}
+			dest[i] = ve.Field(b.fields[i]).Addr().Interface()
+		default:
+			dest[i] = new(any)
+		}
+	}
+	return dest
+}
+
+// computed values of computed columns from scanned destinations. Nil if there are no computed columns
+func (b *scanBinding) computed(dest []any) map[string]any {
+	var result map[string]any
+	for i := range b.columns {
+		if i != b.count && b.values[i] < 0 && b.fields[i] < 0 {
+			if result == nil {
+				result = make(map[string]any)
+			}
+			result[b.columns[i]] = *(dest[i].(*any))
+		}
+	}
+	return result
+}
+
+// SelectColumns select only listed columns. Not selected fields are not filled on Load
+// Computed columns are filled to ignored fields with the same column name or to Computed values
+// Example: c.SelectColumns("id", "name", "price * amount AS total")
+func (c *Collection[T]) SelectColumns(columns ...string) *Collection[T] {
+	c.Columns().Reset()
+	c.Columns().Add(columns...)
+	if c.CountOver >= 0 {
+		c.Columns().Add("COUNT(*) OVER()")
+	}
+	return c
+}
+
+// Computed values of computed columns of loaded item
+// Columns bound to ignored fields are not included
+func (c *Collection[T]) Computed(item *T) map[string]any {
+	return c.computed[item]
+}
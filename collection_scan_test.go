@@ -0,0 +1,59 @@
+package gomodel
+
+import (
+	"testing"
+)
+
+type ScanModel struct {
+	Id    *int     `json:"id" db:"col~id;prk;seq;"`
+	Name  *string  `json:"name" db:"col~name;req;"`
+	Total *float64 `json:"total" db:"col~total;ign;"`
+}
+
+// Model table name
+func (m *ScanModel) Table() string { return "scan_model" }
+
+// Model columns
+func (m *ScanModel) Columns() []string { return []string{"id", "name"} }
+
+// Model values
+func (m *ScanModel) Values() []any { return []any{&m.Id, &m.Name} }
+
+func TestScanBinding(t *testing.T) {
+	t.Run("partial", func(t *testing.T) {
+		model := &ScanModel{}
+		b := newScanBinding(model, []string{"name"}, false)
+		dest := b.destinations(model, nil)
+		if len(dest) != 1 || dest[0] != any(&model.Name) {
+			t.Fatal("name must be bound to model field")
+		}
+		if b.computed(dest) != nil {
+			t.Fatal("must be without computed values")
+		}
+	})
+	t.Run("computed", func(t *testing.T) {
+		model := &ScanModel{}
+		var count int
+		b := newScanBinding(model, []string{"id", "total", "rank", "count"}, true)
+		dest := b.destinations(model, &count)
+		if dest[0] != any(&model.Id) || dest[1] != any(&model.Total) || dest[3] != any(&count) {
+			t.Fatal("wrong destinations")
+		}
+		*(dest[2].(*any)) = int64(1)
+		computed := b.computed(dest)
+		if len(computed) != 1 || computed["rank"] != int64(1) {
+			t.Fatal("wrong computed values")
+		}
+	})
+	t.Run("select_columns", func(t *testing.T) {
+		c := NewCollection[ScanModel]()
+		c.AddCountOver()
+		c.SelectColumns("id", "length(name) AS total")
+		if c.String() != "SELECT id, length(name) AS total, COUNT(*) OVER() FROM scan_model" {
+			t.Fatal("wrong query: " + c.String())
+		}
+		if c.Computed(&ScanModel{}) != nil {
+			t.Fatal("must be without computed values")
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package gomodel
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Model template model of collection. Use its fields to address collection fields
+// Example: m := c.Model(); c.SortBy(&m.Name, false)
+func (c *Collection[T]) Model() *T {
+	if c.model == nil {
+		c.model = new(T)
+	}
+	return c.model
+}
+
+// fieldPosition position of field in model values
+// field - pointer to field of Model() or of any collection item
+func (c *Collection[T]) fieldPosition(field any) int {
+	models := append([]*T{c.Model()}, c.items...)
+	for _, m := range models {
+		model := (interface{})(m).(IModel)
+		column := GetColumn(model, field)
+		if column == "" {
+			continue
+		}
+		columns := model.Columns()
+		for i := range columns {
+			if columns[i] == column {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// fieldValue dereferenced value of item field. Invalid value for nil
+func fieldValue(item any, pos int) reflect.Value {
+	v := reflect.ValueOf((item).(IModel).Values()[pos])
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// IndexBy map of items by primary key
+// Composite key values are joined with comma
+func (c *Collection[T]) IndexBy() map[any]*T {
+	var item interface{} = c.Model()
+	meta := PrepareMetaModel(item.(IModel))
+	var positions []int
+	for i := range meta.Fields {
+		if meta.Fields[i].IsPrimaryKey {
+			positions = append(positions, i)
+		}
+	}
+	result := make(map[any]*T, len(c.items))
+	if len(positions) == 0 {
+		return result
+	}
+	for _, item := range c.items {
+		if len(positions) == 1 {
+			if key, ok := relationKey((interface{})(item).(IModel).Values()[positions[0]]); ok {
+				result[key] = item
+			}
+			continue
+		}
+		keys := make([]string, len(positions))
+		for i, pos := range positions {
+			if v := fieldValue(item, pos); v.IsValid() {
+				keys[i] = fmt.Sprint(v.Interface())
+			}
+		}
+		result[strings.Join(keys, ",")] = item
+	}
+	return result
+}
+
+// GroupByField group items by field value. Items with nil value are grouped by nil key
+// field - pointer to model field
+func (c *Collection[T]) GroupByField(field any) map[any][]*T {
+	result := make(map[any][]*T)
+	pos := c.fieldPosition(field)
+	if pos < 0 {
+		return result
+	}
+	for _, item := range c.items {
+		key, _ := relationKey((interface{})(item).(IModel).Values()[pos])
+		result[key] = append(result[key], item)
+	}
+	return result
+}
+
+// Pluck values of field. Nil values are skipped
+// field - pointer to model field
+func Pluck[V any, T any](c *Collection[T], field any) []V {
+	pos := c.fieldPosition(field)
+	if pos < 0 {
+		return nil
+	}
+	result := make([]V, 0, len(c.items))
+	for _, item := range c.items {
+		if v := fieldValue(item, pos); v.IsValid() {
+			if value, ok := v.Interface().(V); ok {
+				result = append(result, value)
+			}
+		}
+	}
+	return result
+}
+
+// compareValues compare dereferenced field values. Nil is less than any value
+func compareValues(a, b reflect.Value) int {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return -1
+	case !b.IsValid():
+		return 1
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return compareOrdered(boolInt(a.Bool()), boolInt(b.Bool()))
+	}
+	if t, ok := a.Interface().(time.Time); ok {
+		if u := b.Interface().(time.Time); t.Before(u) {
+			return -1
+		} else if t.After(u) {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+// compareOrdered compare numbers
+func compareOrdered[V int64 | uint64 | float64](a, b V) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// boolInt false is 0, true is 1
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// SortBy sort items by field value. Sort is stable. Nil values are first in ascending order
+// field - pointer to model field
+func (c *Collection[T]) SortBy(field any, desc bool) {
+	pos := c.fieldPosition(field)
+	if pos < 0 {
+		return
+	}
+	sort.SliceStable(c.items, func(i, j int) bool {
+		result := compareValues(fieldValue(c.items[i], pos), fieldValue(c.items[j], pos))
+		if desc {
+			return result > 0
+		}
+		return result < 0
+	})
+	c.Reset()
+}
+
+// Chunk split items to chunks of n items
+func (c *Collection[T]) Chunk(n int) [][]*T {
+	if n <= 0 {
+		return nil
+	}
+	chunks := make([][]*T, 0, (len(c.items)+n-1)/n)
+	for i := 0; i < len(c.items); i += n {
+		end := i + n
+		if end > len(c.items) {
+			end = len(c.items)
+		}
+		chunks = append(chunks, c.items[i:end:end])
+	}
+	return chunks
+}
+
+// Find first item matched by callback. Returns nil if not found
+func (c *Collection[T]) Find(callback func(*T) bool) *T {
+	for _, item := range c.items {
+		if callback(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+// Remove items matched by callback. Returns count of removed items
+func (c *Collection[T]) Remove(callback func(*T) bool) int {
+	count := len(c.items)
+	c.Filter(func(item *T) bool {
+		return !callback(item)
+	})
+	c.Reset()
+	return count - len(c.items)
+}
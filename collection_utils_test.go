@@ -0,0 +1,105 @@
+package gomodel
+
+import (
+	"testing"
+	"time"
+)
+
+func newUtilsCollection() *Collection[InsertModel1] {
+	c := NewCollection[InsertModel1]()
+	for i, name := range []string{"foo", "bar", "foo", "baz"} {
+		id, name, some := i+1, name, (i%2)*10
+		created := time.Date(2024, 1, 4-i, 0, 0, 0, 0, time.UTC)
+		c.AddItem(&InsertModel1{Id: &id, Name: &name, SomeInt: &some, CreatedAt: &created})
+	}
+	c.AddItem(&InsertModel1{})
+	return c
+}
+
+func TestCollection_IndexBy(t *testing.T) {
+	index := newUtilsCollection().IndexBy()
+	if len(index) != 4 || *index[3].Name != "foo" || index[5] != nil {
+		t.Fatal("wrong index")
+	}
+}
+
+func TestCollection_GroupByField(t *testing.T) {
+	c := newUtilsCollection()
+	m := c.Model()
+	groups := c.GroupByField(&m.Name)
+	if len(groups) != 4 || len(groups["foo"]) != 2 || len(groups[nil]) != 1 {
+		t.Fatal("wrong groups")
+	}
+	if len(c.GroupByField(&m.Id)) != 5 {
+		t.Fatal("wrong id groups")
+	}
+	if len(c.GroupByField(new(int))) != 0 {
+		t.Fatal("unknown field must be without groups")
+	}
+}
+
+func TestPluck(t *testing.T) {
+	c := newUtilsCollection()
+	m := c.Model()
+	ids := Pluck[int](c, &m.Id)
+	if len(ids) != 4 || ids[0] != 1 || ids[3] != 4 {
+		t.Fatal("wrong ids")
+	}
+	names := Pluck[string](c, &c.First().Name)
+	if len(names) != 4 || names[1] != "bar" {
+		t.Fatal("wrong names")
+	}
+	if Pluck[string](c, &m.Id) == nil || len(Pluck[string](c, &m.Id)) != 0 {
+		t.Fatal("values of another type must be skipped")
+	}
+}
+
+func TestCollection_SortBy(t *testing.T) {
+	c := newUtilsCollection()
+	m := c.Model()
+	c.SortBy(&m.Name, false)
+	if c.First().Name != nil || *c.Items()[1].Name != "bar" || *c.Last().Name != "foo" || *c.Last().Id != 3 {
+		t.Fatal("wrong ascending sort")
+	}
+	c.SortBy(&m.CreatedAt, true)
+	if *c.First().Id != 1 || c.Last().Id != nil {
+		t.Fatal("wrong descending time sort")
+	}
+	c.SortBy(&m.SomeInt, true)
+	if *c.First().Id != 2 || *c.Items()[1].Id != 4 {
+		t.Fatal("wrong descending stable sort")
+	}
+}
+
+func TestCollection_Chunk(t *testing.T) {
+	c := newUtilsCollection()
+	chunks := c.Chunk(2)
+	if len(chunks) != 3 || len(chunks[2]) != 1 || *chunks[1][0].Id != 3 {
+		t.Fatal("wrong chunks")
+	}
+	if c.Chunk(0) != nil || len(NewCollection[InsertModel1]().Chunk(2)) != 0 {
+		t.Fatal("wrong empty chunks")
+	}
+}
+
+func TestCollection_FindRemove(t *testing.T) {
+	c := newUtilsCollection()
+	item := c.Find(func(m *InsertModel1) bool { return m.Name != nil && *m.Name == "foo" })
+	if item == nil || *item.Id != 1 {
+		t.Fatal("wrong found item")
+	}
+	if c.Find(func(m *InsertModel1) bool { return false }) != nil {
+		t.Fatal("item must not be found")
+	}
+	count := c.Remove(func(m *InsertModel1) bool { return m.Name == nil || *m.Name == "foo" })
+	if count != 3 || c.Count() != 2 || *c.First().Name != "bar" {
+		t.Fatal("wrong removed items")
+	}
+	var iterated int
+	for c.Next() {
+		iterated++
+	}
+	if iterated != 2 {
+		t.Fatal("iterator must be reset")
+	}
+}
@@ -0,0 +1,351 @@
+package gomodel
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// Cipher encrypt and decrypt column values
+type Cipher interface {
+	// Encrypt plaintext value
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt ciphertext value
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// AESCipher AES-GCM cipher with key rotation
+// Ciphertext format is "<key id>:<base64(nonce + sealed data)>"
+// New values encrypted with current key, old values decrypted with key by prefix
+type AESCipher struct {
+	// current key id
+	keyId string
+	// aead by key id
+	aeads map[string]cipher.AEAD
+}
+
+// Encrypt plaintext with current key
+func (c *AESCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	aead := c.aeads[c.keyId]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	result := make([]byte, len(c.keyId)+1+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(result, c.keyId)
+	result[len(c.keyId)] = ':'
+	base64.StdEncoding.Encode(result[len(c.keyId)+1:], sealed)
+	return result, nil
+}
+
+// Decrypt ciphertext with key from prefix
+func (c *AESCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	i := bytes.IndexByte(ciphertext, ':')
+	if i < 0 {
+		return nil, errors.New("ciphertext has no key id")
+	}
+	aead, ok := c.aeads[string(ciphertext[:i])]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", ciphertext[:i])
+	}
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(ciphertext)-i-1))
+	n, err := base64.StdEncoding.Decode(sealed, ciphertext[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[:n]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+// KeyId current key id
+func (c *AESCipher) KeyId() string {
+	return c.keyId
+}
+
+// NewAESCipher init AES-GCM cipher
+// keyId - id of key for new values
+// keys - all keys by id. Key length must be 16, 24 or 32 bytes
+func NewAESCipher(keyId string, keys map[string][]byte) (*AESCipher, error) {
+	if _, ok := keys[keyId]; !ok {
+		return nil, fmt.Errorf("key %q is not found", keyId)
+	}
+	c := &AESCipher{keyId: keyId, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if bytes.IndexByte([]byte(id), ':') >= 0 {
+			return nil, fmt.Errorf("key id %q must not contain ':'", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[id], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Encryption encryption settings
+var Encryption = &encryption{}
+
+// encryption type
+type encryption struct {
+	// cipher for enc columns
+	cipher Cipher
+	// key for blind index
+	blindKey []byte
+	// rw mutex
+	m sync.RWMutex
+}
+
+// SetCipher set cipher for enc columns
+func (e *encryption) SetCipher(c Cipher) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.cipher = c
+}
+
+// SetBlindIndexKey set key for blind index columns
+// Key must differ from cipher keys
+func (e *encryption) SetBlindIndexKey(key []byte) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.blindKey = key
+}
+
+// BlindIndex HMAC-SHA256 of value in hex format
+// Use it for equality lookups on bix columns
+func (e *encryption) BlindIndex(value []byte) (string, error) {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	if e.blindKey == nil {
+		return "", errors.New("blind index key is not set")
+	}
+	mac := hmac.New(sha256.New, e.blindKey)
+	mac.Write(value)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// encrypt value with cipher
+func (e *encryption) encrypt(value []byte) ([]byte, error) {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	if e.cipher == nil {
+		return nil, errors.New("cipher is not set")
+	}
+	return e.cipher.Encrypt(value)
+}
+
+// decrypt value with cipher
+func (e *encryption) decrypt(value []byte) ([]byte, error) {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	if e.cipher == nil {
+		return nil, errors.New("cipher is not set")
+	}
+	return e.cipher.Decrypt(value)
+}
+
+// encrypted model fields positions in model values
+type encryptedFields struct {
+	// positions of enc fields
+	enc []int
+	// positions of bix fields and positions of source fields
+	bix [][2]int
+}
+
+// encrypted fields by model type
+var encryptedPositions sync.Map
+
+// getEncryptedFields get positions of encrypted fields. Returns nil if model has no enc and bix fields
+func getEncryptedFields(model IModel) *encryptedFields {
+	te := reflect.TypeOf(model)
+	if fields, ok := encryptedPositions.Load(te); ok {
+		return fields.(*encryptedFields)
+	}
+	var fields *encryptedFields
+	if meta := PrepareMetaModel(model); meta != nil {
+		var result encryptedFields
+		for i := range meta.Fields {
+			if meta.Fields[i].IsEncrypted {
+				result.enc = append(result.enc, i)
+			}
+			if meta.Fields[i].BlindIndex != "" {
+				for j := range meta.Fields {
+					if meta.Fields[j].Column == meta.Fields[i].BlindIndex {
+						result.bix = append(result.bix, [2]int{i, j})
+						break
+					}
+				}
+			}
+		}
+		if len(result.enc) > 0 || len(result.bix) > 0 {
+			fields = &result
+		}
+	}
+	encryptedPositions.Store(te, fields)
+	return fields
+}
+
+// IsEncryptedModel check if model has encrypted or blind index columns
+func IsEncryptedModel(model IModel) bool {
+	return model != nil && getEncryptedFields(model) != nil
+}
+
+// encryptedValue query param encrypted on execution
+type encryptedValue struct {
+	// pointer to model field
+	value any
+}
+
+// Value implementation of driver.Valuer
+func (v encryptedValue) Value() (driver.Value, error) {
+	plaintext, ok, err := fieldBytes(reflect.ValueOf(v.value))
+	if err != nil || !ok {
+		return nil, err
+	}
+	ciphertext, err := Encryption.encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return string(ciphertext), nil
+}
+
+// fieldBytes get bytes of string or []byte field. Pointers are dereferenced
+// ok is false for nil values
+func fieldBytes(v reflect.Value) (data []byte, ok bool, err error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		return []byte(v.String()), true, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		if v.IsNil() {
+			return
+		}
+		return v.Bytes(), true, nil
+	}
+	return nil, false, fmt.Errorf("type %s can not be encrypted", v.Type().String())
+}
+
+// setFieldBytes set bytes to string or []byte field. Nil pointers are initialized
+func setFieldBytes(v reflect.Value, data []byte) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(data))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(data)
+	default:
+		return fmt.Errorf("type %s can not be decrypted", v.Type().String())
+	}
+	return nil
+}
+
+// prepareBlindIndex set bix fields from source fields
+func prepareBlindIndex(model IModel) porterr.IError {
+	fields := getEncryptedFields(model)
+	if fields == nil || len(fields.bix) == 0 {
+		return nil
+	}
+	values := model.Values()
+	for _, pos := range fields.bix {
+		field := reflect.ValueOf(values[pos[0]])
+		source, ok, err := fieldBytes(reflect.ValueOf(values[pos[1]]))
+		if err == nil {
+			if !ok {
+				field.Elem().Set(reflect.Zero(field.Elem().Type()))
+				continue
+			}
+			var index string
+			if index, err = Encryption.BlindIndex(source); err == nil {
+				err = setFieldBytes(field, []byte(index))
+			}
+		}
+		if err != nil {
+			return porterr.New(porterr.PortErrorEncoder, model.Table()+" blind index error: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// encryptSQL replace enc field params with values encrypted on execution
+func encryptSQL(model IModel, isql gosql.ISQL) gosql.ISQL {
+	if isql == nil {
+		return nil
+	}
+	fields := getEncryptedFields(model)
+	if fields == nil || len(fields.enc) == 0 {
+		return isql
+	}
+	values := model.Values()
+	query, params, returning := isql.SQL()
+	result := indexISQL{query: query, params: make([]any, len(params)), returning: returning}
+	for i := range params {
+		result.params[i] = params[i]
+		param := params[i]
+		// byte slices are wrapped as arrays by builders
+		if array, ok := param.(pq.GenericArray); ok {
+			param = array.A
+		}
+		for _, pos := range fields.enc {
+			if param == values[pos] {
+				result.params[i] = encryptedValue{value: param}
+				break
+			}
+		}
+	}
+	return result
+}
+
+// decryptModel decrypt enc fields after scan
+func decryptModel(model IModel) porterr.IError {
+	fields := getEncryptedFields(model)
+	if fields == nil || len(fields.enc) == 0 {
+		return nil
+	}
+	values := model.Values()
+	for _, pos := range fields.enc {
+		field := reflect.ValueOf(values[pos])
+		ciphertext, ok, err := fieldBytes(field)
+		if err == nil && ok {
+			var plaintext []byte
+			if plaintext, err = Encryption.decrypt(ciphertext); err == nil {
+				err = setFieldBytes(field, plaintext)
+			}
+		}
+		if err != nil {
+			return porterr.New(porterr.PortErrorDecoder, model.Table()+" decrypt error: "+err.Error())
+		}
+	}
+	return nil
+}
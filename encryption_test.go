@@ -0,0 +1,171 @@
+package gomodel
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/dimonrus/porterr"
+)
+
+type SecretModel struct {
+	Id         *int    `json:"id" db:"col~id;prk;req;seq;"`
+	Email      *string `json:"email" db:"col~email;req;enc;"`
+	EmailIndex *string `json:"emailIndex" db:"col~email_index;unq;bix~email;"`
+	Token      []byte  `json:"token" db:"col~token;enc;"`
+}
+
+// Model table name
+func (m *SecretModel) Table() string { return "secret_model" }
+
+// Model columns
+func (m *SecretModel) Columns() []string {
+	return []string{"id", "email", "email_index", "token"}
+}
+
+// Model values
+func (m *SecretModel) Values() []any {
+	return []any{&m.Id, &m.Email, &m.EmailIndex, &m.Token}
+}
+
+func testCipher(t *testing.T) *AESCipher {
+	c, err := NewAESCipher("v2", map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 32),
+		"v2": bytes.Repeat([]byte{2}, 32),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAESCipher(t *testing.T) {
+	c := testCipher(t)
+	ciphertext, err := c.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(ciphertext), "v2:") {
+		t.Fatal("wrong key id prefix")
+	}
+	plaintext, err := c.Decrypt(ciphertext)
+	if err != nil || string(plaintext) != "secret" {
+		t.Fatal("wrong decrypted value")
+	}
+	t.Run("rotation", func(t *testing.T) {
+		old, _ := NewAESCipher("v1", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)})
+		ciphertext, _ := old.Encrypt([]byte("old secret"))
+		plaintext, err := c.Decrypt(ciphertext)
+		if err != nil || string(plaintext) != "old secret" {
+			t.Fatal("value of rotated key must be decrypted")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		if _, err := c.Decrypt([]byte("v3:AAAA")); err == nil {
+			t.Fatal("must be unknown key error")
+		}
+		if _, err := c.Decrypt([]byte("secret")); err == nil {
+			t.Fatal("must be key id error")
+		}
+		if _, err := NewAESCipher("v3", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)}); err == nil {
+			t.Fatal("must be not found key error")
+		}
+		if _, err := NewAESCipher("v1", map[string][]byte{"v1": []byte("short")}); err == nil {
+			t.Fatal("must be key size error")
+		}
+	})
+}
+
+func TestEncryption(t *testing.T) {
+	Encryption.SetCipher(testCipher(t))
+	Encryption.SetBlindIndexKey([]byte("blind"))
+	defer Encryption.SetCipher(nil)
+	defer Encryption.SetBlindIndexKey(nil)
+
+	email := "foo@bar.com"
+	m := &SecretModel{Email: &email, Token: []byte("token")}
+	t.Run("insert", func(t *testing.T) {
+		query, params, _ := GetInsertSQL(m).SQL()
+		t.Log(query)
+		if query != "INSERT INTO secret_model (email, email_index, token) VALUES (?, ?, ?) RETURNING id;" {
+			t.Fatal("wrong insert query")
+		}
+		index, _ := Encryption.BlindIndex([]byte(email))
+		if m.EmailIndex == nil || *m.EmailIndex != index || len(index) != 64 {
+			t.Fatal("wrong blind index")
+		}
+		if params[1] != &m.EmailIndex {
+			t.Fatal("blind index must not be encrypted")
+		}
+		for _, i := range []int{0, 2} {
+			v, ok := params[i].(driver.Valuer)
+			if !ok {
+				t.Fatal("param must be encrypted")
+			}
+			value, err := v.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(value.(string), "v2:") {
+				t.Fatal("wrong encrypted value")
+			}
+		}
+	})
+	t.Run("decrypt", func(t *testing.T) {
+		encEmail, _ := encryptedValue{value: &m.Email}.Value()
+		encToken, _ := encryptedValue{value: &m.Token}.Value()
+		loaded := &SecretModel{Token: []byte(encToken.(string))}
+		value := encEmail.(string)
+		loaded.Email = &value
+		if e := decryptModel(loaded); e != nil {
+			t.Fatal(e)
+		}
+		if *loaded.Email != email || string(loaded.Token) != "token" {
+			t.Fatal("wrong decrypted model")
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		value, err := encryptedValue{value: &(&SecretModel{}).Email}.Value()
+		if err != nil || value != nil {
+			t.Fatal("nil value must not be encrypted")
+		}
+		if e := decryptModel(&SecretModel{}); e != nil {
+			t.Fatal(e)
+		}
+	})
+	t.Run("no_cipher", func(t *testing.T) {
+		Encryption.SetCipher(nil)
+		defer Encryption.SetCipher(testCipher(t))
+		if _, err := (encryptedValue{value: &m.Email}).Value(); err == nil {
+			t.Fatal("must be an error")
+		}
+	})
+}
+
+func TestNotEncryptedModel(t *testing.T) {
+	if IsEncryptedModel(&InsertModel1{}) || !IsEncryptedModel(&SecretModel{}) {
+		t.Fatal("wrong encrypted model check")
+	}
+}
+
+func TestBlindIndexError(t *testing.T) {
+	Encryption.SetCipher(testCipher(t))
+	defer Encryption.SetCipher(nil)
+	email := "foo@bar.com"
+	dbo, fake := newFakeDBO(nil)
+	if e := Save(dbo, &SecretModel{Email: &email}); e == nil || e.GetCode() != porterr.PortErrorEncoder {
+		t.Fatal("must be blind index error")
+	}
+	collection := NewCollection[SecretModel]()
+	collection.AddItem(&SecretModel{Email: &email})
+	if e := collection.Save(dbo); e == nil || e.GetCode() != porterr.PortErrorEncoder {
+		t.Fatal("collection must return blind index error")
+	}
+	if _, e := Explain(&SecretModel{Email: &email}, IndexOperationCreate); e == nil || e.GetCode() != porterr.PortErrorEncoder {
+		t.Fatal("explain must return blind index error")
+	}
+	if len(fake.log()) != 0 {
+		t.Fatal("query must not be executed")
+	}
+}
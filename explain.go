@@ -0,0 +1,190 @@
+package gomodel
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// ExplainParam bound query parameter
+type ExplainParam struct {
+	// Column model column of parameter. Empty if parameter is not a model field
+	Column string
+	// Value dereferenced parameter value
+	Value any
+	// Clock value of application clock
+	Clock bool
+	// Encrypted value is encrypted on execution
+	Encrypted bool
+}
+
+// Explanation report of query produced by model operation
+type Explanation struct {
+	// Table model table
+	Table string
+	// Operation model operation
+	Operation IndexOperation
+	// Scenario save scenario: insert, update or upsert. Empty for other operations
+	Scenario string
+	// Query rendered query
+	Query string
+	// Params bound parameters in query order
+	Params []ExplainParam
+	// Returning model columns of returning targets
+	Returning []string
+	// Cached query was taken from IndexCache
+	Cached bool
+}
+
+// String report for logs and test failure messages
+func (e Explanation) String() string {
+	b := strings.Builder{}
+	b.WriteString(string(e.Operation) + " " + e.Table)
+	if e.Scenario != "" {
+		b.WriteString(" scenario=" + e.Scenario)
+	}
+	b.WriteString(" cached=" + strconv.FormatBool(e.Cached))
+	b.WriteString("\n" + e.Query)
+	for i, param := range e.Params {
+		b.WriteString("\n$" + strconv.Itoa(i+1) + " ")
+		if param.Column != "" {
+			b.WriteString(param.Column + " = ")
+		}
+		switch {
+		case param.Clock:
+			b.WriteString("<clock> ")
+		case param.Encrypted:
+			b.WriteString("<encrypted> ")
+		}
+		b.WriteString(fmt.Sprintf("%#v", param.Value))
+	}
+	if len(e.Returning) > 0 {
+		b.WriteString("\nreturning: " + strings.Join(e.Returning, ", "))
+	}
+	return b.String()
+}
+
+// Explain query of model operation without execution
+// op - IndexOperationLoad, IndexOperationCreate, IndexOperationUpdate, IndexOperationSave,
+// IndexOperationDelete, IndexOperationDeleteReturning or IndexOperationRestore
+// fields - fields for create and update
+// Query is built with separate cache, so IndexCache and its hits and misses are not changed
+func Explain(model IModel, op IndexOperation, fields ...any) (*Explanation, porterr.IError) {
+	meta := PrepareMetaModel(model)
+	if meta == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Model must not be nil")
+	}
+	explanation := &Explanation{Table: model.Table(), Operation: op}
+	var isql gosql.ISQL
+	var e porterr.IError
+	ic := newCache()
+	switch op {
+	case IndexOperationLoad:
+		explanation.Cached = IndexCache.Has(op, model)
+		isql = getLoadSQL(ic, model, IndexOperationLoad, "")
+	case IndexOperationCreate:
+		explanation.Cached = IndexCache.Has(op, model, fields...)
+		isql, e = getInsertSQL(ic, model, fields...)
+	case IndexOperationUpdate:
+		explanation.Cached = IndexCache.Has(op, model, fields...)
+		isql, e = getUpdateSQL(ic, model, fields...)
+	case IndexOperationSave:
+		insert, update, upsert := getSaveScenario(model)
+		switch {
+		case insert:
+			explanation.Scenario, explanation.Cached = "insert", IndexCache.Has(IndexOperationCreate, model)
+		case update:
+			explanation.Scenario, explanation.Cached = "update", IndexCache.Has(IndexOperationUpdate, model)
+		case upsert:
+			explanation.Scenario, explanation.Cached = "upsert", IndexCache.Has(IndexOperationSave, model)
+		}
+		isql, e = getSaveSQL(ic, model)
+	case IndexOperationDelete:
+		explanation.Cached = IndexCache.Has(op, model)
+		isql = getDeleteSQL(ic, model, IndexOperationDelete, false)
+	case IndexOperationDeleteReturning:
+		explanation.Cached = IndexCache.Has(op, model)
+		isql = getDeleteSQL(ic, model, IndexOperationDeleteReturning, true)
+	case IndexOperationRestore:
+		explanation.Cached = IndexCache.Has(op, model)
+		isql = getRestoreSQL(ic, model)
+	default:
+		return nil, porterr.New(porterr.PortErrorArgument, "Operation "+string(op)+" is not supported")
+	}
+	if e != nil {
+		return nil, e
+	}
+	if isql == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "ISQL is empty. Check model keys, tenant and fields")
+	}
+	query, params, returning := isql.SQL()
+	explanation.Query = query
+	explanation.Params = make([]ExplainParam, len(params))
+	for i := range params {
+		param := params[i]
+		if v, ok := param.(encryptedValue); ok {
+			explanation.Params[i].Encrypted = true
+			param = v.value
+		}
+		if _, ok := param.(clockValue); ok {
+			explanation.Params[i].Clock, explanation.Params[i].Value = true, Now().UTC()
+			continue
+		}
+		explanation.Params[i].Column = explainColumn(meta, param)
+		explanation.Params[i].Value = explainValue(param)
+	}
+	explanation.Returning = make([]string, len(returning))
+	for i := range returning {
+		explanation.Returning[i] = explainColumn(meta, returning[i])
+	}
+	return explanation, nil
+}
+
+// explainPointer address of value. Arrays wrapped by pq.Array are unwrapped
+func explainPointer(value any) uintptr {
+	if array, ok := value.(pq.GenericArray); ok {
+		value = array.A
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return 0
+	}
+	return v.Pointer()
+}
+
+// explainColumn column of model field referenced by value
+func explainColumn(meta *MetaModel, value any) string {
+	ptr := explainPointer(value)
+	if ptr == 0 {
+		return ""
+	}
+	for i := range meta.Fields {
+		if explainPointer(meta.Fields[i].Value) == ptr {
+			return meta.Fields[i].Column
+		}
+	}
+	return ""
+}
+
+// explainValue dereferenced value. Nil for nil pointer
+func explainValue(value any) any {
+	if array, ok := value.(pq.GenericArray); ok {
+		value = array.A
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
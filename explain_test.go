@@ -0,0 +1,63 @@
+package gomodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+	IndexCache.Reset()
+	id, name := 1, "name"
+	t.Run("save", func(t *testing.T) {
+		model := &InsertModel1{Id: &id, Name: &name, Pages: []string{"a"}}
+		hits, misses := IndexCache.Stat()
+		for i := 0; i < 2; i++ {
+			if i > 0 {
+				if h, m := IndexCache.Stat(); h != hits || m != misses || IndexCache.Has(IndexOperationUpdate, model) {
+					t.Fatal("explain must not change index cache")
+				}
+				GetSaveSQL(model)
+			}
+			explanation, e := Explain(model, IndexOperationSave)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if explanation.Scenario != "update" || explanation.Cached != (i > 0) {
+				t.Fatal("wrong scenario or cached: " + explanation.String())
+			}
+			if len(explanation.Params) != 4 || explanation.Params[0].Column != "name" || explanation.Params[0].Value != "name" || explanation.Params[1].Column != "pages" || explanation.Params[3].Column != "id" || explanation.Params[3].Value != 1 {
+				t.Fatal("wrong params: " + explanation.String())
+			}
+			if strings.Join(explanation.Returning, ",") != "created_at,updated_at,deleted_at" {
+				t.Fatal("wrong returning: " + explanation.String())
+			}
+		}
+		explanation, _ := Explain(&InsertModel1{Name: &name}, IndexOperationSave)
+		if explanation.Scenario != "insert" || !strings.HasPrefix(explanation.Query, "INSERT INTO test_model_1") {
+			t.Fatal("wrong insert scenario: " + explanation.String())
+		}
+	})
+	t.Run("clock", func(t *testing.T) {
+		SetClock(FrozenClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+		defer SetClock(nil)
+		explanation, e := Explain(&InsertModel1{Id: &id}, IndexOperationDelete)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !explanation.Params[0].Clock || explanation.Params[0].Column != "" || explanation.Params[2].Column != "id" {
+			t.Fatal("wrong clock params: " + explanation.String())
+		}
+		if !strings.Contains(explanation.String(), "$1 <clock> time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)") {
+			t.Fatal("wrong string: " + explanation.String())
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		if _, e := Explain(&InsertModel1{Id: &id}, IndexOperation("unknown")); e == nil {
+			t.Fatal("unknown operation must be error")
+		}
+		if _, e := Explain(&TenantModel{}, IndexOperationLoad); e == nil {
+			t.Fatal("model without tenant must be error")
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/dimonrus/godb/v2"
+)
+
+// fakeResult scripted result of fake database query
+type fakeResult struct {
+	// columns of returned rows
+	columns []string
+	// returned rows
+	rows [][]driver.Value
+	// count of affected rows
+	affected int64
+	// query error
+	err error
+}
+
+// fakeDB database with scripted results. Executed queries are logged
+type fakeDB struct {
+	// result of query
+	handler func(query string, args []driver.Value) fakeResult
+	// executed queries with args
+	queries []string
+	args    [][]driver.Value
+	m       sync.Mutex
+}
+
+// newFakeDBO init DBO with scripted results
+func newFakeDBO(handler func(query string, args []driver.Value) fakeResult) (*godb.DBO, *fakeDB) {
+	db := &fakeDB{handler: handler}
+	return &godb.DBO{DB: sql.OpenDB(db)}, db
+}
+
+// Connect implementation of driver.Connector
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+
+// Driver implementation of driver.Connector
+func (db *fakeDB) Driver() driver.Driver { return nil }
+
+// run log and handle query
+func (db *fakeDB) run(query string, args []driver.NamedValue) fakeResult {
+	values := make([]driver.Value, len(args))
+	for i := range args {
+		values[i] = args[i].Value
+	}
+	db.m.Lock()
+	db.queries = append(db.queries, query)
+	db.args = append(db.args, values)
+	db.m.Unlock()
+	if db.handler == nil || strings.HasPrefix(query, "SAVEPOINT") || strings.HasPrefix(query, "RELEASE") {
+		return fakeResult{}
+	}
+	return db.handler(query, values)
+}
+
+// log of executed queries
+func (db *fakeDB) log() []string {
+	db.m.Lock()
+	defer db.m.Unlock()
+	return append([]string(nil), db.queries...)
+}
+
+// fakeConn connection of fake database
+type fakeConn struct {
+	db *fakeDB
+}
+
+// Prepare implementation of driver.Conn
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+
+// Close implementation of driver.Conn
+func (c *fakeConn) Close() error { return nil }
+
+// Begin implementation of driver.Conn
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.run("BEGIN", nil)
+	return c, nil
+}
+
+// Commit implementation of driver.Tx
+func (c *fakeConn) Commit() error {
+	c.db.run("COMMIT", nil)
+	return nil
+}
+
+// Rollback implementation of driver.Tx
+func (c *fakeConn) Rollback() error {
+	c.db.run("ROLLBACK", nil)
+	return nil
+}
+
+// CheckNamedValue convert value by default converter. Not convertible values like slices are passed as is
+func (c *fakeConn) CheckNamedValue(v *driver.NamedValue) error {
+	if value, err := driver.DefaultParameterConverter.ConvertValue(v.Value); err == nil {
+		v.Value = value
+	}
+	return nil
+}
+
+// ExecContext implementation of driver.ExecerContext
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+// QueryContext implementation of driver.QueryerContext
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+// fakeRows rows of fake database
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// Columns implementation of driver.Rows
+func (r *fakeRows) Columns() []string { return r.columns }
+
+// Close implementation of driver.Rows
+func (r *fakeRows) Close() error { return nil }
+
+// Next implementation of driver.Rows
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,115 @@
+package gomodel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// PortErrorLockTimeout error code of lock not available. Lock timeout or NOWAIT lock of locked row
+const PortErrorLockTimeout = "PORTABLE_ERROR_LOCK_TIMEOUT"
+
+// LockStrength row lock strength
+type LockStrength string
+
+const (
+	// LockForUpdate lock rows for update or delete
+	LockForUpdate LockStrength = "FOR UPDATE"
+	// LockForNoKeyUpdate lock rows for update of not key columns
+	LockForNoKeyUpdate LockStrength = "FOR NO KEY UPDATE"
+	// LockForShare shared lock of rows
+	LockForShare LockStrength = "FOR SHARE"
+	// LockForKeyShare shared lock of row keys
+	LockForKeyShare LockStrength = "FOR KEY SHARE"
+)
+
+// LockWait behaviour on locked rows
+type LockWait string
+
+const (
+	// LockWaitDefault wait for lock release
+	LockWaitDefault LockWait = ""
+	// LockNoWait return error on locked rows
+	LockNoWait LockWait = "NOWAIT"
+	// LockSkipLocked skip locked rows
+	LockSkipLocked LockWait = "SKIP LOCKED"
+)
+
+// LockOptions row lock options
+type LockOptions struct {
+	// Strength lock strength. LockForUpdate by default
+	Strength LockStrength
+	// Wait behaviour on locked rows
+	Wait LockWait
+}
+
+// String lock clause. Example: FOR UPDATE NOWAIT
+func (o LockOptions) String() string {
+	clause := string(o.Strength)
+	if clause == "" {
+		clause = string(LockForUpdate)
+	}
+	if o.Wait != LockWaitDefault {
+		clause += " " + string(o.Wait)
+	}
+	return clause
+}
+
+// operation cache operation of load with lock. Example: load_for_update_nowait
+func (o LockOptions) operation() IndexOperation {
+	return IndexOperation(string(IndexOperationLoad) + "_" + strings.ReplaceAll(strings.ToLower(o.String()), " ", "_"))
+}
+
+// GetLoadForUpdateSQL return sql query for load model with row lock
+func GetLoadForUpdateSQL(model IModel, options LockOptions) gosql.ISQL {
+	return getLoadSQL(IndexCache, model, options.operation(), options.String())
+}
+
+// LoadForUpdate load model and lock its row
+// q must be a transaction. Lock is released on transaction end
+func LoadForUpdate(q godb.Queryer, model IModel, options LockOptions) porterr.IError {
+	return LoadForUpdateContext(context.Background(), q, model, options)
+}
+
+// LoadForUpdateContext load model and lock its row
+// ctx - context with tenant for multi-tenant models
+func LoadForUpdateContext(ctx context.Context, q godb.Queryer, model IModel, options LockOptions) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e == nil {
+		if e = Do(q, GetLoadForUpdateSQL(model, options)); e == nil {
+			e = decryptModel(model)
+		}
+	}
+	Metrics.Observe(model.Table(), IndexOperationLoad, start, e)
+	return
+}
+
+// Lock lock loaded rows. Collection must be loaded in transaction
+func (c *Collection[T]) Lock(options LockOptions) *Collection[T] {
+	c.lock = options.String()
+	return c
+}
+
+// query collection query with row lock clause
+// Row lock is not allowed with count over window function
+func (c *Collection[T]) query() (string, porterr.IError) {
+	if c.lock == "" {
+		return c.String(), nil
+	}
+	if c.CountOver >= 0 {
+		return "", porterr.New(porterr.PortErrorArgument, "Row lock is not allowed with count over. Remove count over or lock")
+	}
+	return c.String() + " " + c.lock, nil
+}
+
+// isLockError check if error is lock not available error
+func isLockError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55P03"
+}
@@ -0,0 +1,91 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+func TestLockOptions(t *testing.T) {
+	if (LockOptions{}).String() != "FOR UPDATE" {
+		t.Fatal("FOR UPDATE must be default")
+	}
+	options := LockOptions{Strength: LockForNoKeyUpdate, Wait: LockSkipLocked}
+	if options.String() != "FOR NO KEY UPDATE SKIP LOCKED" || options.operation() != "load_for_no_key_update_skip_locked" {
+		t.Fatal("wrong lock clause")
+	}
+}
+
+func TestGetLoadForUpdateSQL(t *testing.T) {
+	id := 10
+	model := &InsertModel1{Id: &id}
+	query, params, returning := GetLoadForUpdateSQL(model, LockOptions{Wait: LockNoWait}).SQL()
+	expected := "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (id = ? AND deleted_at IS NULL) FOR UPDATE NOWAIT"
+	if query != expected || len(params) != 1 || len(returning) != 7 {
+		t.Fatal("wrong lock query: " + query)
+	}
+	// cached query
+	query, _, _ = GetLoadForUpdateSQL(model, LockOptions{Wait: LockNoWait}).SQL()
+	if query != expected {
+		t.Fatal("wrong cached lock query: " + query)
+	}
+	query, _, _ = GetLoadForUpdateSQL(model, LockOptions{Strength: LockForShare}).SQL()
+	if query != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (id = ? AND deleted_at IS NULL) FOR SHARE" {
+		t.Fatal("wrong share lock query: " + query)
+	}
+	query, _, _ = GetLoadSQL(model).SQL()
+	if query != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (id = ? AND deleted_at IS NULL)" {
+		t.Fatal("load query must be without lock: " + query)
+	}
+}
+
+func TestCollection_Lock(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	c.Where().AddExpression("id > ?", 1)
+	if query, _ := c.query(); query != c.String() {
+		t.Fatal("query must be without lock")
+	}
+	c.Lock(LockOptions{Wait: LockSkipLocked})
+	if query, _ := c.query(); query != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (id > ?) FOR UPDATE SKIP LOCKED" {
+		t.Fatal("wrong lock query: " + query)
+	}
+	c.AddCountOver()
+	if _, e := c.query(); e == nil || e.GetCode() != porterr.PortErrorArgument {
+		t.Fatal("lock with count over must be rejected")
+	}
+	if _, e := c.declareSQL("gomodel_cursor_1"); e == nil {
+		t.Fatal("cursor with lock and count over must be rejected")
+	}
+}
+
+func TestNewDatabaseError_LockTimeout(t *testing.T) {
+	e := NewDatabaseError(&pq.Error{Code: "55P03", Message: "could not obtain lock"})
+	if e.GetCode() != PortErrorLockTimeout || e.Origin().Name != "lock_not_available" {
+		t.Fatal("wrong lock timeout error")
+	}
+	if NewDatabaseError(errors.New("some")).GetCode() == PortErrorLockTimeout {
+		t.Fatal("wrong error code")
+	}
+}
+
+func TestCollection_LockTimeout(t *testing.T) {
+	var err error = &pq.Error{Code: "55P03", Message: "could not obtain lock"}
+	dbo, _ := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		return fakeResult{err: err}
+	})
+	c := NewCollection[InsertModel1]()
+	c.Lock(LockOptions{Wait: LockNoWait})
+	if e := c.Load(dbo); e == nil || e.GetCode() != PortErrorLockTimeout {
+		t.Fatal("collection must return lock timeout error")
+	}
+	for _, err = range []error{&pq.Error{Code: "42P01", Message: "undefined table"}, errors.New("some")} {
+		e := c.Load(dbo)
+		if e == nil || e.GetCode() != porterr.PortErrorDatabaseQuery || !strings.HasPrefix(e.Error(), "Collection search query error: ") {
+			t.Fatal("other errors must be collection search query errors")
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package gomodel
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"github.com/dimonrus/godb/v2"
 	"github.com/dimonrus/gohelp"
 	"github.com/dimonrus/gosql"
 	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -150,40 +157,242 @@ func GetValues(model IModel, columns ...string) (values []any) {
 	return
 }
 
+// keyJSON model key values in json format. Example {"id": 10}
+func keyJSON(model IModel) ([]byte, error) {
+	meta := PrepareMetaModel(model)
+	if meta == nil {
+		return []byte("{}"), nil
+	}
+	keys := meta.Fields.Keys()
+	key := make(map[string]any, len(keys))
+	for i := range keys {
+		key[keys[i].Column] = keys[i].Value
+	}
+	return json.Marshal(key)
+}
+
+// DoOptions options of query execution
+type DoOptions struct {
+	// ExactlyOne require exactly one affected row
+	// Query is already executed when error returned. Use transaction to rollback changes of several rows
+	ExactlyOne bool
+}
+
 // Do exec query on model
-func Do(q godb.Queryer, isql gosql.ISQL) (e porterr.IError) {
+// Update or delete without affected rows returns not found error
+func Do(q godb.Queryer, isql gosql.ISQL) porterr.IError {
+	return DoWithOptions(q, isql, DoOptions{})
+}
+
+// DoWithOptions exec query on model with options
+func DoWithOptions(q godb.Queryer, isql gosql.ISQL, options DoOptions) (e porterr.IError) {
 	if isql == nil {
-		e = porterr.New(porterr.PortErrorLoad, "ISQL is empty. Check your logic")
+		e = errorEmptySQL()
 		return
 	}
 	var err error
+	var affected int64 = -1
 	query, params, returning := isql.SQL()
-	if len(returning) > 0 {
+	params = bindClock(q, params)
+	switch {
+	case len(returning) > 0 && options.ExactlyOne:
+		affected, err = queryFirst(q, query, params, returning)
+	case len(returning) > 0:
 		err = q.QueryRow(query, params...).Scan(returning...)
-	} else {
-		_, err = q.Exec(query, params...)
+	default:
+		var result sql.Result
+		if result, err = q.Exec(query, params...); err == nil && (options.ExactlyOne || isModification(query)) {
+			affected, err = result.RowsAffected()
+		}
 	}
 	if err != nil {
 		if err == sql.ErrNoRows {
-			e = porterr.New(porterr.PortErrorSearch, "No record found. Check params or model already deleted").HTTP(http.StatusNotFound)
+			e = errorNotFound()
 		} else {
-			e = porterr.New(porterr.PortErrorIO, err.Error())
+			e = NewDatabaseError(err)
 		}
+		return
+	}
+	if affected == 0 {
+		e = errorNotFound()
+	} else if affected > 1 && options.ExactlyOne {
+		e = porterr.New(porterr.PortErrorConflict, "Query affected "+strconv.FormatInt(affected, 10)+" rows, expected exactly one").HTTP(http.StatusConflict)
 	}
 	return
 }
 
+// errorEmptySQL error of empty query
+func errorEmptySQL() porterr.IError {
+	return porterr.New(porterr.PortErrorLoad, "ISQL is empty. Check your logic")
+}
+
+// errorNotFound error of query without result rows or affected rows
+func errorNotFound() porterr.IError {
+	return porterr.New(porterr.PortErrorSearch, "No record found. Check params or model already deleted").HTTP(http.StatusNotFound)
+}
+
+// isModification check if query is update or delete. Cached queries are checked by query text
+func isModification(query string) bool {
+	return strings.HasPrefix(query, "UPDATE ") || strings.HasPrefix(query, "DELETE ")
+}
+
+// queryFirst scan first returned row and count all returned rows
+func queryFirst(q godb.Queryer, query string, params []any, returning []any) (count int64, err error) {
+	rows, err := q.Query(query, params...)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if count == 0 {
+			if err = rows.Scan(returning...); err != nil {
+				return
+			}
+		}
+		count++
+	}
+	err = rows.Err()
+	return
+}
+
+// NewDatabaseError convert database error to porterr
+// For postgres errors name of error is a condition name. Example "serialization_failure"
+// Lock not available error has PortErrorLockTimeout code
+func NewDatabaseError(err error) porterr.IError {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if pqErr.Code == "55P03" {
+			return porterr.NewWithName(PortErrorLockTimeout, pqErr.Code.Name(), err.Error())
+		}
+		return porterr.NewWithName(porterr.PortErrorIO, pqErr.Code.Name(), err.Error())
+	}
+	return porterr.New(porterr.PortErrorIO, err.Error())
+}
+
 // Load get isql and load model
 func Load(q godb.Queryer, model IModel) porterr.IError {
-	return Do(q, GetLoadSQL(model))
+	return LoadContext(context.Background(), q, model)
+}
+
+// LoadContext get isql and load model
+// ctx - context with tenant for multi-tenant models
+func LoadContext(ctx context.Context, q godb.Queryer, model IModel) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e == nil {
+		if e = Do(q, GetLoadSQL(model)); e == nil {
+			e = decryptModel(model)
+		}
+	}
+	Metrics.Observe(model.Table(), IndexOperationLoad, start, e)
+	return
 }
 
 // Save get isql and save model
 func Save(q godb.Queryer, model IModel) porterr.IError {
-	return Do(q, GetSaveSQL(model))
+	return SaveContext(context.Background(), q, model)
+}
+
+// SaveContext get isql and save model
+// ctx - context with actor for audit and tenant for multi-tenant models
+// Outbox events are inserted with the same queryer. Use transaction to save them atomically
+func SaveContext(ctx context.Context, q godb.Queryer, model IModel) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e != nil {
+		Metrics.Observe(model.Table(), IndexOperationSave, start, e)
+		return
+	}
+	e = Audit.track(ctx, q, model, IndexOperationSave, func() porterr.IError {
+		isql, e := getSaveSQL(IndexCache, model)
+		if e != nil {
+			return e
+		}
+		if e = Do(q, isql); e != nil {
+			return e
+		}
+		return Outbox.emit(q, model, IndexOperationSave)
+	})
+	Metrics.Observe(model.Table(), IndexOperationSave, start, e)
+	return
 }
 
 // Delete get isql and delete model
 func Delete(q godb.Queryer, model IModel) porterr.IError {
-	return Do(q, GetDeleteSQL(model))
+	return DeleteContext(context.Background(), q, model)
+}
+
+// DeleteContext get isql and delete model
+// ctx - context with actor for audit and tenant for multi-tenant models
+// Outbox events are inserted with the same queryer. Use transaction to save them atomically
+// Dependent rows registered in Cascade are soft deleted in the same transaction
+func DeleteContext(ctx context.Context, q godb.Queryer, model IModel) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e != nil {
+		Metrics.Observe(model.Table(), IndexOperationDelete, start, e)
+		return
+	}
+	e = Cascade.run(q, model, false, func(q godb.Queryer) porterr.IError {
+		return Audit.track(ctx, q, model, IndexOperationDelete, func() porterr.IError {
+			if e := Do(q, GetDeleteSQL(model)); e != nil {
+				return e
+			}
+			return Outbox.emit(q, model, IndexOperationDelete)
+		})
+	})
+	Metrics.Observe(model.Table(), IndexOperationDelete, start, e)
+	return
+}
+
+// DeleteReturning delete model and load all columns of deleted row into model
+func DeleteReturning(q godb.Queryer, model IModel) porterr.IError {
+	return DeleteReturningContext(context.Background(), q, model)
+}
+
+// DeleteReturningContext delete model and load all columns of deleted row into model
+// ctx - context with actor for audit and tenant for multi-tenant models
+// Outbox events are inserted with the same queryer. Use transaction to save them atomically
+func DeleteReturningContext(ctx context.Context, q godb.Queryer, model IModel) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e != nil {
+		Metrics.Observe(model.Table(), IndexOperationDelete, start, e)
+		return
+	}
+	e = Cascade.run(q, model, false, func(q godb.Queryer) porterr.IError {
+		return Audit.track(ctx, q, model, IndexOperationDelete, func() porterr.IError {
+			if e := Do(q, GetDeleteReturningSQL(model)); e != nil {
+				return e
+			}
+			if e := decryptModel(model); e != nil {
+				return e
+			}
+			return Outbox.emit(q, model, IndexOperationDelete)
+		})
+	})
+	Metrics.Observe(model.Table(), IndexOperationDelete, start, e)
+	return
+}
+
+// Restore get isql and restore soft deleted model
+func Restore(q godb.Queryer, model IModel) porterr.IError {
+	return RestoreContext(context.Background(), q, model)
+}
+
+// RestoreContext get isql and restore soft deleted model
+// ctx - context with actor for audit and tenant for multi-tenant models
+// Dependent rows registered in Cascade and deleted together with model are restored in the same transaction
+func RestoreContext(ctx context.Context, q godb.Queryer, model IModel) (e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e != nil {
+		Metrics.Observe(model.Table(), IndexOperationRestore, start, e)
+		return
+	}
+	e = Cascade.run(q, model, true, func(q godb.Queryer) porterr.IError {
+		return Audit.track(ctx, q, model, IndexOperationRestore, func() porterr.IError {
+			if e := Do(q, GetRestoreSQL(model)); e != nil {
+				return e
+			}
+			return Outbox.emit(q, model, IndexOperationSave)
+		})
+	})
+	Metrics.Observe(model.Table(), IndexOperationRestore, start, e)
+	return
 }
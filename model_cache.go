@@ -3,6 +3,7 @@ package gomodel
 import (
 	"github.com/dimonrus/gosql"
 	"sync"
+	"sync/atomic"
 )
 
 // IndexOperation type of operation for specific model
@@ -21,6 +22,10 @@ const (
 	IndexOperationUpdate IndexOperation = "update"
 	// IndexOperationDelete delete operation
 	IndexOperationDelete IndexOperation = "delete"
+	// IndexOperationDeleteReturning delete operation returning deleted row
+	IndexOperationDeleteReturning IndexOperation = "delete_returning"
+	// IndexOperationRestore restore of soft deleted model
+	IndexOperationRestore IndexOperation = "restore"
 	// IndexOperationSave save operation
 	IndexOperationSave IndexOperation = "save"
 
@@ -29,9 +34,14 @@ const (
 )
 
 // IndexCache index cache object
-var IndexCache = &cache{
-	models:  make(map[ModelOperation]Index, IndexCacheDefaultLength),
-	columns: make(map[string][]string, 16),
+var IndexCache = newCache()
+
+// newCache init empty cache
+func newCache() *cache {
+	return &cache{
+		models:  make(map[ModelOperation]Index, IndexCacheDefaultLength),
+		columns: make(map[string][]string, 16),
+	}
 }
 
 // cache type
@@ -42,6 +52,10 @@ type cache struct {
 	columns map[string][]string
 	// rw mutex
 	m sync.RWMutex
+	// count of found indexes
+	hits uint64
+	// count of not found indexes
+	misses uint64
 }
 
 // set model columns to cache
@@ -76,11 +90,26 @@ func (c *cache) Get(io IndexOperation, model IModel, field ...any) gosql.ISQL {
 	}
 	key := c.Key(io, table, columns, values, field...)
 	if v, ok := c.models[key]; ok {
+		atomic.AddUint64(&c.hits, 1)
 		return v.ToISQL(values)
 	}
+	atomic.AddUint64(&c.misses, 1)
 	return nil
 }
 
+// Has check if query of model operation is cached. Hits and misses are not changed
+func (c *cache) Has(io IndexOperation, model IModel, field ...any) bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	_, ok := c.models[c.Key(io, model.Table(), model.Columns(), model.Values(), field...)]
+	return ok
+}
+
+// Stat count of cache hits and misses
+func (c *cache) Stat() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
 // Reset map
 func (c *cache) Reset() {
 	c.m.Lock()
@@ -158,7 +187,11 @@ func (c *Index) ToISQL(values []any) gosql.ISQL {
 		returning: make([]any, len(c.returningPos)),
 	}
 	for i := range index.params {
-		index.params[i] = values[c.paramsPos[i]]
+		if c.paramsPos[i] == clockPos {
+			index.params[i] = clockValue{}
+		} else {
+			index.params[i] = values[c.paramsPos[i]]
+		}
 	}
 	for i := range index.returning {
 		index.returning[i] = values[c.returningPos[i]]
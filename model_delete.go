@@ -7,8 +7,25 @@ import (
 
 // GetDeleteSQL model delete query
 // model - target model
-func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
-	isql := IndexCache.Get(IndexOperationDelete, model)
+func GetDeleteSQL(model IModel) gosql.ISQL {
+	return getDeleteSQL(IndexCache, model, IndexOperationDelete, false)
+}
+
+// GetDeleteReturningSQL model delete query returning all columns of deleted row
+// model - target model
+func GetDeleteReturningSQL(model IModel) gosql.ISQL {
+	return getDeleteSQL(IndexCache, model, IndexOperationDeleteReturning, true)
+}
+
+// getDeleteSQL model delete query
+// ic - query cache
+// io - cache operation
+// all - return all columns of deleted row
+func getDeleteSQL(ic *cache, model IModel, io IndexOperation, all bool) (iSQL gosql.ISQL) {
+	if !isTenantSet(model) {
+		return
+	}
+	isql := ic.Get(io, model)
 	if isql != nil {
 		return isql
 	}
@@ -20,6 +37,7 @@ func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
 	idx := InitIndex(meta.Fields.Len())
 	if meta.Fields.IsSoft() {
 		upd := gosql.NewUpdate()
+		var setPos []int16
 		for i := range meta.Fields {
 			if meta.Fields[i].IsPrimaryKey {
 				hasPrimaryKey = true
@@ -29,7 +47,7 @@ func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
 					} else {
 						upd.Where().AddExpression(meta.Fields[i].Column+" = ?", meta.Fields[i].Value)
 					}
-					idx.AppendParamPos(int16(meta.Fields[i].Index))
+					idx.AppendParamPos(int16(i))
 				}
 			} else if meta.Fields[i].IsUnique && !hasPrimaryKey {
 				if !meta.Fields[i].IsNil {
@@ -38,18 +56,42 @@ func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
 					} else {
 						upd.Where().AddExpression(meta.Fields[i].Column+" = ?", meta.Fields[i].Value)
 					}
-					idx.AppendParamPos(int16(meta.Fields[i].Index))
+					idx.AppendParamPos(int16(i))
 				}
 			} else if meta.Fields[i].IsDeletedAt {
-				upd.Set().Append(meta.Fields[i].Column + " = NOW()")
-				upd.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
-				idx.AppendReturningPos(int16(meta.Fields[i].Index))
+				expr, args := setNow(meta.Fields[i].Column)
+				upd.Set().Append(expr, args...)
+				if len(args) > 0 {
+					setPos = append(setPos, clockPos)
+				}
+				if !all {
+					upd.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
+					idx.AppendReturningPos(int16(i))
+				}
 			} else if meta.Fields[i].IsUpdatedAt {
-				upd.Set().Append(meta.Fields[i].Column + " = NOW()")
+				expr, args := setNow(meta.Fields[i].Column)
+				upd.Set().Append(expr, args...)
+				if len(args) > 0 {
+					setPos = append(setPos, clockPos)
+				}
+				if !all {
+					upd.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
+					idx.AppendReturningPos(int16(i))
+				}
+			}
+		}
+		if all {
+			for i := range meta.Fields {
 				upd.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
-				idx.AppendReturningPos(int16(meta.Fields[i].Index))
+				idx.AppendReturningPos(int16(i))
 			}
 		}
+		if tenant := meta.Fields.Tenant(); tenant != nil && !upd.Where().IsEmpty() {
+			upd.Where().AddExpression(tenant.Column+" = ?", tenant.Value)
+			idx.AppendParamPos(int16(columnPosition(meta.Fields, tenant.Column)))
+		}
+		// set parameters are before where parameters
+		idx.PrependParamPos(setPos...)
 		if !upd.Where().IsEmpty() {
 			upd.Table(model.Table())
 			iSQL = upd
@@ -66,7 +108,7 @@ func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
 					} else {
 						del.Where().AddExpression(meta.Fields[i].Column+" = ?", meta.Fields[i].Value)
 					}
-					idx.AppendParamPos(int16(meta.Fields[i].Index))
+					idx.AppendParamPos(int16(i))
 				}
 			} else if meta.Fields[i].IsUnique && !hasPrimaryKey {
 				if meta.Fields[i].Value != nil {
@@ -75,16 +117,100 @@ func GetDeleteSQL(model IModel) (iSQL gosql.ISQL) {
 					} else {
 						del.Where().AddExpression(meta.Fields[i].Column+" = ?", meta.Fields[i].Value)
 					}
-					idx.AppendParamPos(int16(meta.Fields[i].Index))
+					idx.AppendParamPos(int16(i))
 				}
 			}
 		}
+		if tenant := meta.Fields.Tenant(); tenant != nil && !del.Where().IsEmpty() {
+			del.Where().AddExpression(tenant.Column+" = ?", tenant.Value)
+			idx.AppendParamPos(int16(columnPosition(meta.Fields, tenant.Column)))
+		}
+		if all {
+			for i := range meta.Fields {
+				del.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
+				idx.AppendReturningPos(int16(i))
+			}
+		}
 		if !del.Where().IsEmpty() {
 			del.From(model.Table())
 			iSQL = del
 			idx.SetQuery(del.String())
 		}
 	}
-	IndexCache.Store(IndexCache.Key(IndexOperationDelete, model.Table(), model.Columns(), model.Values()), idx)
+	ic.Store(IndexCache.Key(io, model.Table(), model.Columns(), model.Values()), idx)
 	return iSQL
 }
+
+// GetRestoreSQL soft deleted model restore query
+// Returns nil for not soft model
+func GetRestoreSQL(model IModel) gosql.ISQL {
+	return getRestoreSQL(IndexCache, model)
+}
+
+// getRestoreSQL soft deleted model restore query
+// ic - query cache
+func getRestoreSQL(ic *cache, model IModel) gosql.ISQL {
+	if !isTenantSet(model) {
+		return nil
+	}
+	isql := ic.Get(IndexOperationRestore, model)
+	if isql != nil {
+		return isql
+	}
+	meta := PrepareMetaModel(model)
+	if meta == nil || !meta.Fields.IsSoft() {
+		return nil
+	}
+	idx := InitIndex(meta.Fields.Len())
+	upd := gosql.NewUpdate()
+	cond := restoreCondition(meta, &idx)
+	if cond.IsEmpty() {
+		return nil
+	}
+	upd.Where().Replace(cond)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsDeletedAt {
+			upd.Set().Append(meta.Fields[i].Column + " = NULL")
+		} else if meta.Fields[i].IsUpdatedAt {
+			expr, args := setNow(meta.Fields[i].Column)
+			upd.Set().Append(expr, args...)
+			if len(args) > 0 {
+				idx.PrependParamPos(clockPos)
+			}
+		} else {
+			continue
+		}
+		upd.Returning().Append(meta.Fields[i].Column, meta.Fields[i].Value)
+		idx.AppendReturningPos(int16(i))
+	}
+	upd.Table(model.Table())
+	idx.SetQuery(upd.String())
+	ic.Store(IndexCache.Key(IndexOperationRestore, model.Table(), model.Columns(), model.Values()), idx)
+	return upd
+}
+
+// restoreCondition primary or unique key and tenant condition of soft model
+func restoreCondition(meta *MetaModel, idx *Index) *gosql.Condition {
+	var hasPrimaryKey bool
+	cond := gosql.NewSqlCondition(gosql.ConditionOperatorAnd)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsPrimaryKey {
+			hasPrimaryKey = true
+		} else if !meta.Fields[i].IsUnique || hasPrimaryKey {
+			continue
+		}
+		if !meta.Fields[i].IsNil {
+			if meta.Fields[i].IsArray {
+				cond.AddExpression(meta.Fields[i].Column+" = ?", pq.Array(meta.Fields[i].Value))
+			} else {
+				cond.AddExpression(meta.Fields[i].Column+" = ?", meta.Fields[i].Value)
+			}
+			idx.AppendParamPos(int16(i))
+		}
+	}
+	if tenant := meta.Fields.Tenant(); tenant != nil && !cond.IsEmpty() {
+		cond.AddExpression(tenant.Column+" = ?", tenant.Value)
+		idx.AppendParamPos(int16(columnPosition(meta.Fields, tenant.Column)))
+	}
+	return cond
+}
@@ -2,6 +2,7 @@ package gomodel
 
 import (
 	"testing"
+	"time"
 )
 
 func TestGetDeleteSQL(t *testing.T) {
@@ -111,6 +112,43 @@ func TestGetDeleteSQL(t *testing.T) {
 	})
 }
 
+func TestGetDeleteReturningSQL(t *testing.T) {
+	t.Run("classic", func(t *testing.T) {
+		model := &DeleteModel1{}
+		model.Id = &ACMId
+		for i := 0; i < 2; i++ {
+			query, params, returning := GetDeleteReturningSQL(model).SQL()
+			if query != "DELETE FROM test_model_del_1 WHERE (id = ?) RETURNING id, name, pages, some_int, created_at, updated_at;" {
+				t.Fatal("classic wrong query: " + query)
+			}
+			if len(params) != 1 || len(returning) != len(model.Values()) {
+				t.Fatal("classic wrong params or returning")
+			}
+			// pages is wrapped by new pq array on each Values call
+			for _, j := range []int{0, 1, 3, 4, 5} {
+				if returning[j] != model.Values()[j] {
+					t.Fatal("classic wrong returning ref")
+				}
+			}
+		}
+		query, _, _ := GetDeleteSQL(model).SQL()
+		if query != "DELETE FROM test_model_del_1 WHERE (id = ?);" {
+			t.Fatal("delete query must not be changed: " + query)
+		}
+	})
+	t.Run("soft", func(t *testing.T) {
+		model := &InsertModel1{}
+		model.Id = &ACMId
+		query, _, returning := GetDeleteReturningSQL(model).SQL()
+		if query != "UPDATE test_model_1 SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ?) RETURNING id, name, pages, some_int, created_at, updated_at, deleted_at;" {
+			t.Fatal("soft wrong query: " + query)
+		}
+		if len(returning) != len(model.Values()) {
+			t.Fatal("soft wrong returning")
+		}
+	})
+}
+
 func BenchmarkName(b *testing.B) {
 	// goos: darwin
 	// goarch: arm64
@@ -144,3 +182,48 @@ func BenchmarkName(b *testing.B) {
 		b.ReportAllocs()
 	})
 }
+
+type DeleteIgnoredModel struct {
+	Id        *int       `json:"id" db:"col~id;prk;req;seq;"`
+	Score     *int       `json:"score" db:"col~score;ign;"`
+	Name      *string    `json:"name" db:"col~name;"`
+	TenantId  *int       `json:"tenantId" db:"col~tenant_id;tnt;req;"`
+	DeletedAt *time.Time `json:"deletedAt" db:"col~deleted_at;dat;"`
+}
+
+// Model table name
+func (m *DeleteIgnoredModel) Table() string { return "test_model_ignored" }
+
+// Model columns
+func (m *DeleteIgnoredModel) Columns() []string {
+	return []string{"id", "name", "tenant_id", "deleted_at"}
+}
+
+// Model values
+func (m *DeleteIgnoredModel) Values() []any {
+	return []any{&m.Id, &m.Name, &m.TenantId, &m.DeletedAt}
+}
+
+func TestGetDeleteReturningSQL_Ignored(t *testing.T) {
+	id, tenant := 1, 2
+	model := &DeleteIgnoredModel{Id: &id, TenantId: &tenant}
+	for i := 0; i < 2; i++ {
+		query, params, returning := GetDeleteReturningSQL(model).SQL()
+		if query != "UPDATE test_model_ignored SET deleted_at = NOW() WHERE (id = ? AND tenant_id = ?) RETURNING id, name, tenant_id, deleted_at;" {
+			t.Fatal("wrong query: " + query)
+		}
+		values := model.Values()
+		if len(params) != 2 || params[0] != values[0] || params[1] != values[2] {
+			t.Fatal("wrong params")
+		}
+		for j := range returning {
+			if returning[j] != values[j] {
+				t.Fatal("wrong returning ref")
+			}
+		}
+		query, params, _ = GetDeleteSQL(model).SQL()
+		if query != "UPDATE test_model_ignored SET deleted_at = NOW() WHERE (id = ? AND tenant_id = ?) RETURNING deleted_at;" || params[1] != values[2] {
+			t.Fatal("wrong delete query: " + query)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package gomodel
 
 import (
 	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
 	"github.com/lib/pq"
 	"reflect"
 	"strings"
@@ -9,9 +10,31 @@ import (
 
 // GetInsertSQL model insert query
 func GetInsertSQL(model IModel, fields ...any) gosql.ISQL {
-	isql := IndexCache.Get(IndexOperationCreate, model, fields...)
+	isql, _ := getInsertSQL(IndexCache, model, fields...)
+	return isql
+}
+
+// getInsertSQL model insert query. Blind index error is returned
+// ic - query cache
+func getInsertSQL(ic *cache, model IModel, fields ...any) (gosql.ISQL, porterr.IError) {
+	if !isTenantSet(model) {
+		return nil, errorEmptySQL()
+	}
+	if e := prepareBlindIndex(model); e != nil {
+		return nil, e
+	}
+	isql := insertSQL(ic, model, fields...)
+	if isql == nil {
+		return nil, errorEmptySQL()
+	}
+	return isql, nil
+}
+
+// insertSQL model insert query with prepared blind index
+func insertSQL(ic *cache, model IModel, fields ...any) gosql.ISQL {
+	isql := ic.Get(IndexOperationCreate, model, fields...)
 	if isql != nil {
-		return isql
+		return encryptSQL(model, isql)
 	}
 	meta := PrepareMetaModel(model)
 	idx := InitIndex(meta.Fields.Len())
@@ -31,8 +54,13 @@ func GetInsertSQL(model IModel, fields ...any) gosql.ISQL {
 	} else {
 		values = model.Values()
 	}
+	var tenantPos = -1
 	for j := 0; j < meta.Fields.Len(); j++ {
 		tField := meta.Fields[j]
+		if tField.IsTenant {
+			tenantPos = j
+			continue
+		}
 		for i := range values {
 			fv := reflect.ValueOf(values[i])
 			if fv.Kind() != reflect.Ptr {
@@ -124,6 +152,13 @@ func GetInsertSQL(model IModel, fields ...any) gosql.ISQL {
 			}
 		}
 	}
+	if tenantPos >= 0 && !insert.IsEmpty() {
+		insert.Columns().Append(meta.Fields[tenantPos].Column, meta.Fields[tenantPos].Value)
+		idx.AppendParamPos(int16(tenantPos))
+		if isConflict {
+			insert.Conflict().Where().AddExpression(model.Table() + "." + meta.Fields[tenantPos].Column + " = EXCLUDED." + meta.Fields[tenantPos].Column)
+		}
+	}
 	if !insert.IsEmpty() {
 		insert.Into(model.Table())
 		if isConflict {
@@ -137,6 +172,6 @@ func GetInsertSQL(model IModel, fields ...any) gosql.ISQL {
 		}
 	}
 	idx.SetQuery(insert.String())
-	IndexCache.Store(IndexCache.Key(IndexOperationCreate, model.Table(), model.Columns(), model.Values(), fields...), idx)
-	return insert
+	ic.Store(IndexCache.Key(IndexOperationCreate, model.Table(), model.Columns(), model.Values(), fields...), idx)
+	return encryptSQL(model, insert)
 }
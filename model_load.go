@@ -6,7 +6,17 @@ import (
 
 // GetLoadSQL return sql query fot load model
 func GetLoadSQL(model IModel) gosql.ISQL {
-	isql := IndexCache.Get(IndexOperationLoad, model)
+	return getLoadSQL(IndexCache, model, IndexOperationLoad, "")
+}
+
+// getLoadSQL return sql query for load model with row lock clause
+// ic - query cache
+// io - cache operation. Must be different for each lock clause
+func getLoadSQL(ic *cache, model IModel, io IndexOperation, lock string) gosql.ISQL {
+	if !isTenantSet(model) {
+		return nil
+	}
+	isql := ic.Get(io, model)
 	if isql != nil {
 		return isql
 	}
@@ -18,12 +28,15 @@ func GetLoadSQL(model IModel) gosql.ISQL {
 	selectSql.From(model.Table())
 	cond := gosql.NewSqlCondition(gosql.ConditionOperatorAnd)
 	idx := InitIndex(meta.Fields.Len())
+	var tenantPos = -1
 	for i := 0; i < meta.Fields.Len(); i++ {
 		tField := meta.Fields[i]
 		if tField.IsIgnored || tField.Column == "" {
 			continue
 		}
-		if tField.IsPrimaryKey && !tField.IsNil {
+		if tField.IsTenant {
+			tenantPos = i
+		} else if tField.IsPrimaryKey && !tField.IsNil {
 			cond.AddExpression(tField.Column+" = ?", tField.Value)
 			idx.AppendParamPos(int16(i))
 		} else if tField.IsUnique && !tField.IsNil {
@@ -37,10 +50,19 @@ func GetLoadSQL(model IModel) gosql.ISQL {
 		selectSql.Columns().Append(tField.Column, tField.Value)
 		idx.AppendReturningPos(int16(i))
 	}
+	if tenantPos >= 0 {
+		cond.AddExpression(meta.Fields[tenantPos].Column+" = ?", meta.Fields[tenantPos].Value)
+		idx.AppendParamPos(int16(tenantPos))
+	}
 	if !cond.IsEmpty() {
 		selectSql.Where().Replace(cond)
 	}
+	if lock != "" {
+		idx.SetQuery(selectSql.String() + " " + lock)
+		ic.Store(IndexCache.Key(io, model.Table(), model.Columns(), model.Values()), idx)
+		return idx.ToISQL(model.Values())
+	}
 	idx.SetQuery(selectSql.String())
-	IndexCache.Store(IndexCache.Key(IndexOperationLoad, model.Table(), model.Columns(), model.Values()), idx)
+	ic.Store(IndexCache.Key(io, model.Table(), model.Columns(), model.Values()), idx)
 	return selectSql
 }
@@ -0,0 +1,236 @@
+package gomodel
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimonrus/porterr"
+)
+
+// MetricsExpvarName name of expvar variable with model metrics
+const MetricsExpvarName = "gomodel"
+
+// MetricsBuckets latency histogram upper bounds in seconds
+var MetricsBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Metrics model operation metrics registry
+var Metrics = &metrics{
+	operations: make(map[operationKey]*operationStat, IndexCacheDefaultLength),
+	errors:     make(map[errorKey]uint64, IndexCacheDefaultLength),
+}
+
+func init() {
+	expvar.Publish(MetricsExpvarName, expvar.Func(func() any { return Metrics.Snapshot() }))
+}
+
+// key of table operation
+type operationKey struct {
+	// model table
+	table string
+	// operation
+	operation IndexOperation
+}
+
+// key of table operation error
+type errorKey struct {
+	operationKey
+	// porterr code
+	code string
+}
+
+// operation counters and latency histogram
+type operationStat struct {
+	// count of operations
+	count uint64
+	// sum of latency in seconds
+	sum float64
+	// cumulative counters per bucket. len(buckets) == len(MetricsBuckets)
+	buckets []uint64
+}
+
+// metrics type
+type metrics struct {
+	// operations stats
+	operations map[operationKey]*operationStat
+	// errors by code
+	errors map[errorKey]uint64
+	// rw mutex
+	m sync.RWMutex
+}
+
+// Observe register model operation result
+// start - time when operation was started
+func (s *metrics) Observe(table string, io IndexOperation, start time.Time, e porterr.IError) {
+	seconds := time.Since(start).Seconds()
+	key := operationKey{table: table, operation: io}
+	s.m.Lock()
+	defer s.m.Unlock()
+	stat, ok := s.operations[key]
+	if !ok {
+		stat = &operationStat{buckets: make([]uint64, len(MetricsBuckets))}
+		s.operations[key] = stat
+	}
+	stat.count++
+	stat.sum += seconds
+	for i := range MetricsBuckets {
+		if seconds <= MetricsBuckets[i] {
+			stat.buckets[i]++
+		}
+	}
+	if e != nil {
+		s.errors[errorKey{operationKey: key, code: fmt.Sprint(e.GetCode())}]++
+	}
+}
+
+// Reset all collected metrics
+func (s *metrics) Reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.operations = make(map[operationKey]*operationStat, IndexCacheDefaultLength)
+	s.errors = make(map[errorKey]uint64, IndexCacheDefaultLength)
+}
+
+// OperationSnapshot metrics of table operation
+type OperationSnapshot struct {
+	// Table name
+	Table string `json:"table"`
+	// Operation
+	Operation IndexOperation `json:"operation"`
+	// Count of operations
+	Count uint64 `json:"count"`
+	// Sum of latency in seconds
+	Sum float64 `json:"sum"`
+	// Cumulative counters per bucket. Key is a bucket upper bound
+	Buckets map[string]uint64 `json:"buckets"`
+	// Errors count by porterr code
+	Errors map[string]uint64 `json:"errors,omitempty"`
+}
+
+// MetricsSnapshot copy of all metrics
+type MetricsSnapshot struct {
+	// Operations metrics
+	Operations []OperationSnapshot `json:"operations"`
+	// IndexCache hits
+	CacheHits uint64 `json:"cacheHits"`
+	// IndexCache misses
+	CacheMisses uint64 `json:"cacheMisses"`
+	// IndexCache hit ratio
+	CacheHitRatio float64 `json:"cacheHitRatio"`
+}
+
+// Snapshot copy metrics. Operations sorted by table and operation
+func (s *metrics) Snapshot() MetricsSnapshot {
+	var snapshot MetricsSnapshot
+	s.m.RLock()
+	snapshot.Operations = make([]OperationSnapshot, 0, len(s.operations))
+	for key, stat := range s.operations {
+		item := OperationSnapshot{
+			Table:     key.table,
+			Operation: key.operation,
+			Count:     stat.count,
+			Sum:       stat.sum,
+			Buckets:   make(map[string]uint64, len(stat.buckets)),
+		}
+		for i := range stat.buckets {
+			item.Buckets[formatFloat(MetricsBuckets[i])] = stat.buckets[i]
+		}
+		for ek, count := range s.errors {
+			if ek.operationKey == key {
+				if item.Errors == nil {
+					item.Errors = make(map[string]uint64)
+				}
+				item.Errors[ek.code] = count
+			}
+		}
+		snapshot.Operations = append(snapshot.Operations, item)
+	}
+	s.m.RUnlock()
+	sort.Slice(snapshot.Operations, func(i, j int) bool {
+		if snapshot.Operations[i].Table == snapshot.Operations[j].Table {
+			return snapshot.Operations[i].Operation < snapshot.Operations[j].Operation
+		}
+		return snapshot.Operations[i].Table < snapshot.Operations[j].Table
+	})
+	snapshot.CacheHits, snapshot.CacheMisses = IndexCache.Stat()
+	if total := snapshot.CacheHits + snapshot.CacheMisses; total > 0 {
+		snapshot.CacheHitRatio = float64(snapshot.CacheHits) / float64(total)
+	}
+	return snapshot
+}
+
+// WritePrometheus write metrics in prometheus text format
+func (s *metrics) WritePrometheus(b *strings.Builder) {
+	snapshot := s.Snapshot()
+	b.WriteString("# HELP gomodel_operations_total Count of model operations.\n")
+	b.WriteString("# TYPE gomodel_operations_total counter\n")
+	for _, o := range snapshot.Operations {
+		b.WriteString("gomodel_operations_total{" + labels(o.Table, o.Operation) + "} " + strconv.FormatUint(o.Count, 10) + "\n")
+	}
+	b.WriteString("# HELP gomodel_operation_errors_total Count of failed model operations by error code.\n")
+	b.WriteString("# TYPE gomodel_operation_errors_total counter\n")
+	for _, o := range snapshot.Operations {
+		codes := make([]string, 0, len(o.Errors))
+		for code := range o.Errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			b.WriteString("gomodel_operation_errors_total{" + labels(o.Table, o.Operation) + `,code="` + escapeLabel(code) + `"} ` + strconv.FormatUint(o.Errors[code], 10) + "\n")
+		}
+	}
+	b.WriteString("# HELP gomodel_operation_duration_seconds Latency of model operations.\n")
+	b.WriteString("# TYPE gomodel_operation_duration_seconds histogram\n")
+	for _, o := range snapshot.Operations {
+		l := labels(o.Table, o.Operation)
+		for _, bound := range MetricsBuckets {
+			le := formatFloat(bound)
+			b.WriteString("gomodel_operation_duration_seconds_bucket{" + l + `,le="` + le + `"} ` + strconv.FormatUint(o.Buckets[le], 10) + "\n")
+		}
+		b.WriteString("gomodel_operation_duration_seconds_bucket{" + l + `,le="+Inf"} ` + strconv.FormatUint(o.Count, 10) + "\n")
+		b.WriteString("gomodel_operation_duration_seconds_sum{" + l + "} " + formatFloat(o.Sum) + "\n")
+		b.WriteString("gomodel_operation_duration_seconds_count{" + l + "} " + strconv.FormatUint(o.Count, 10) + "\n")
+	}
+	b.WriteString("# HELP gomodel_index_cache_hits_total Count of IndexCache hits.\n")
+	b.WriteString("# TYPE gomodel_index_cache_hits_total counter\n")
+	b.WriteString("gomodel_index_cache_hits_total " + strconv.FormatUint(snapshot.CacheHits, 10) + "\n")
+	b.WriteString("# HELP gomodel_index_cache_misses_total Count of IndexCache misses.\n")
+	b.WriteString("# TYPE gomodel_index_cache_misses_total counter\n")
+	b.WriteString("gomodel_index_cache_misses_total " + strconv.FormatUint(snapshot.CacheMisses, 10) + "\n")
+	b.WriteString("# HELP gomodel_index_cache_hit_ratio Ratio of IndexCache hits.\n")
+	b.WriteString("# TYPE gomodel_index_cache_hit_ratio gauge\n")
+	b.WriteString("gomodel_index_cache_hit_ratio " + formatFloat(snapshot.CacheHitRatio) + "\n")
+}
+
+// ServeHTTP implementation of http.Handler. Render prometheus text format
+func (s *metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b := strings.Builder{}
+	s.WritePrometheus(&b)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// MetricsHandler http handler with metrics in prometheus text format
+func MetricsHandler() http.Handler {
+	return Metrics
+}
+
+// table and operation labels
+func labels(table string, io IndexOperation) string {
+	return `table="` + escapeLabel(table) + `",operation="` + escapeLabel(string(io)) + `"`
+}
+
+// escape prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// format float for metrics
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,80 @@
+package gomodel
+
+import (
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimonrus/porterr"
+)
+
+func TestMetrics(t *testing.T) {
+	Metrics.Reset()
+	start := time.Now().Add(-time.Millisecond * 3)
+	Metrics.Observe("test_model_1", IndexOperationLoad, start, nil)
+	Metrics.Observe("test_model_1", IndexOperationLoad, start, porterr.New(porterr.PortErrorSearch, "not found"))
+	Metrics.Observe("test_model_1", IndexOperationSave, time.Now(), nil)
+
+	t.Run("snapshot", func(t *testing.T) {
+		snapshot := Metrics.Snapshot()
+		if len(snapshot.Operations) != 2 {
+			t.Fatal("wrong operations len")
+		}
+		load := snapshot.Operations[0]
+		if load.Operation != IndexOperationLoad || load.Table != "test_model_1" {
+			t.Fatal("wrong operations order")
+		}
+		if load.Count != 2 {
+			t.Fatal("wrong load count")
+		}
+		if load.Errors[porterr.PortErrorSearch] != 1 {
+			t.Fatal("wrong load errors")
+		}
+		if load.Buckets["0.001"] != 0 || load.Buckets["5"] != 2 {
+			t.Fatal("wrong load buckets")
+		}
+		if snapshot.Operations[1].Errors != nil {
+			t.Fatal("save must be without errors")
+		}
+	})
+	t.Run("prometheus", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		body := w.Body.String()
+		t.Log(body)
+		if !strings.Contains(body, `gomodel_operations_total{table="test_model_1",operation="load"} 2`) {
+			t.Fatal("wrong operations total")
+		}
+		if !strings.Contains(body, `gomodel_operation_errors_total{table="test_model_1",operation="load",code="PORTABLE_ERROR_SEARCH"} 1`) {
+			t.Fatal("wrong errors total")
+		}
+		if !strings.Contains(body, `gomodel_operation_duration_seconds_bucket{table="test_model_1",operation="save",le="+Inf"} 1`) {
+			t.Fatal("wrong histogram")
+		}
+		if !strings.Contains(body, "gomodel_index_cache_hit_ratio ") {
+			t.Fatal("wrong cache ratio")
+		}
+	})
+	t.Run("expvar", func(t *testing.T) {
+		v := expvar.Get(MetricsExpvarName)
+		if v == nil {
+			t.Fatal("must be published")
+		}
+		if !strings.Contains(v.String(), `"operation":"save"`) {
+			t.Fatal("wrong expvar value")
+		}
+	})
+	t.Run("cache_stat", func(t *testing.T) {
+		m := InsertModel1{Id: &ACMId}
+		GetLoadSQL(&m)
+		hits, _ := IndexCache.Stat()
+		GetLoadSQL(&m)
+		after, _ := IndexCache.Stat()
+		if after != hits+1 {
+			t.Fatal("wrong cache hits")
+		}
+	})
+	Metrics.Reset()
+}
@@ -2,6 +2,7 @@ package gomodel
 
 import (
 	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
 	"github.com/lib/pq"
 	"reflect"
 	"strings"
@@ -11,17 +12,39 @@ import (
 // it can be insert or update or upsert query
 // some popular scenario was implemented. not all
 func GetSaveSQL(model IModel) gosql.ISQL {
+	isql, _ := getSaveSQL(IndexCache, model)
+	return isql
+}
+
+// getSaveSQL prepare a save query. Blind index error is returned
+// ic - query cache
+func getSaveSQL(ic *cache, model IModel) (gosql.ISQL, porterr.IError) {
+	if !isTenantSet(model) {
+		return nil, errorEmptySQL()
+	}
+	if e := prepareBlindIndex(model); e != nil {
+		return nil, e
+	}
+	isql := saveSQL(ic, model)
+	if isql == nil {
+		return nil, errorEmptySQL()
+	}
+	return isql, nil
+}
+
+// saveSQL prepare a save query of model with prepared blind index
+func saveSQL(ic *cache, model IModel) gosql.ISQL {
 	var result gosql.ISQL
 	insert, update, upsert := getSaveScenario(model)
 	if insert {
-		result = IndexCache.Get(IndexOperationCreate, model)
+		result = ic.Get(IndexOperationCreate, model)
 	} else if update {
-		result = IndexCache.Get(IndexOperationUpdate, model)
+		result = ic.Get(IndexOperationUpdate, model)
 	} else if upsert {
-		result = IndexCache.Get(IndexOperationSave, model)
+		result = ic.Get(IndexOperationSave, model)
 	}
 	if result != nil {
-		return result
+		return encryptSQL(model, result)
 	}
 	meta := PrepareMetaModel(model)
 	if meta == nil {
@@ -40,10 +63,13 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 	var upsertPos = make([]int16, 0, meta.Fields.Len())
 	var conflictColumns = strings.Builder{}
 
+	var tenantPos = -1
 	var tField ModelFiledTag
 	for i := 0; i < meta.Fields.Len(); i++ {
 		tField = meta.Fields[i]
-		if tField.IsPrimaryKey {
+		if tField.IsTenant {
+			tenantPos = i
+		} else if tField.IsPrimaryKey {
 			hasPrimaryKey = true
 			if !tField.IsNil {
 				if tField.IsSequence {
@@ -131,7 +157,11 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 					returning.Append(tField.Column, tField.Value)
 					idx.AppendReturningPos(int16(i))
 				} else if tField.IsUpdatedAt {
-					columnsUpdate.Append(tField.Column + " = NOW()")
+					expr, args := setNow(tField.Column)
+					columnsUpdate.Append(expr, args...)
+					if len(args) > 0 {
+						upsertPos = append(upsertPos, clockPos)
+					}
 					returning.Append(tField.Column, tField.Value)
 					idx.AppendReturningPos(int16(i))
 				} else if tField.IsDeletedAt {
@@ -154,7 +184,11 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 		uQuery.Table(model.Table())
 		if columnsUpdate.Len() > 0 {
 			uQuery.Set().Append(columnsUpdate.String(", "), columnsUpdate.GetArguments()...)
-			idx.AppendParamPos(columnPos...)
+			idx.AppendParamPos(upsertPos...)
+		}
+		if tenantPos >= 0 {
+			condition.AddExpression(meta.Fields[tenantPos].Column+" = ?", meta.Fields[tenantPos].Value)
+			conditionPos = append(conditionPos, int16(tenantPos))
 		}
 		if condition != nil {
 			uQuery.Where().Replace(condition)
@@ -171,6 +205,10 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 		insertQuery.Columns().Add(columnsInsert.Split()...)
 		insertQuery.Columns().Arg(columnsInsert.GetArguments()...)
 		idx.AppendParamPos(columnPos...)
+		if tenantPos >= 0 {
+			insertQuery.Columns().Append(meta.Fields[tenantPos].Column, meta.Fields[tenantPos].Value)
+			idx.AppendParamPos(int16(tenantPos))
+		}
 		if returning.Len() > 0 {
 			insertQuery.Returning().Append(returning.String(", "), returning.GetArguments()...)
 		}
@@ -182,6 +220,11 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 		upsertQuery.Columns().Add(columnsInsert.Split()...)
 		upsertQuery.Columns().Arg(columnsInsert.GetArguments()...)
 		idx.AppendParamPos(columnPos...)
+		if tenantPos >= 0 {
+			upsertQuery.Columns().Append(meta.Fields[tenantPos].Column, meta.Fields[tenantPos].Value)
+			idx.AppendParamPos(int16(tenantPos))
+			conflict.Where().AddExpression(model.Table() + "." + meta.Fields[tenantPos].Column + " = EXCLUDED." + meta.Fields[tenantPos].Column)
+		}
 		if returning.Len() > 0 {
 			upsertQuery.Returning().Append(returning.String(", "), returning.GetArguments()...)
 		}
@@ -205,8 +248,8 @@ func GetSaveSQL(model IModel) gosql.ISQL {
 	} else if upsert {
 		key = IndexCache.Key(IndexOperationSave, model.Table(), model.Columns(), model.Values())
 	}
-	IndexCache.Store(key, idx)
-	return result
+	ic.Store(key, idx)
+	return encryptSQL(model, result)
 }
 
 // getSaveScenario check model for save scenario
@@ -42,6 +42,55 @@ func (l ModelFiledTagList) IsSoft() bool {
 	return false
 }
 
+// Tenant get tenant field. Returns nil if model is not multi-tenant
+func (l ModelFiledTagList) Tenant() *ModelFiledTag {
+	for i := range l {
+		if l[i].IsTenant {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
+// Keys fields identify model row. Primary key fields or first not nil unique field
+func (l ModelFiledTagList) Keys() ModelFiledTagList {
+	var keys ModelFiledTagList
+	for i := range l {
+		if l[i].IsPrimaryKey {
+			keys = append(keys, l[i])
+		}
+	}
+	if len(keys) > 0 {
+		return keys
+	}
+	for i := range l {
+		if l[i].IsUnique && !l[i].IsNil {
+			return append(keys, l[i])
+		}
+	}
+	return keys
+}
+
+// ByValue get field by pointer to model field. Returns nil if not found
+func (l ModelFiledTagList) ByValue(value any) *ModelFiledTag {
+	for i := range l {
+		if l[i].Value == value {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
+// ByColumn get field by column name. Returns nil if not found
+func (l ModelFiledTagList) ByColumn(column string) *ModelFiledTag {
+	for i := range l {
+		if l[i].Column == column {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
 // ModelFiledTag All possible model field tag properties
 // tag must have 3 symbol lengths
 type ModelFiledTag struct {
@@ -71,6 +120,12 @@ type ModelFiledTag struct {
 	IsIgnored bool `tag:"ign"`
 	// Is array value
 	IsArray bool `tag:"arr"`
+	// Is tenant column
+	IsTenant bool `tag:"tnt"`
+	// Is encrypted column
+	IsEncrypted bool `tag:"enc"`
+	// Blind index of encrypted column
+	BlindIndex string `tag:"bix"`
 	// If is zero
 	IsZero bool
 	// If is nil
@@ -90,12 +145,31 @@ func (t *ModelFiledTag) Clear() {
 	t.IsDeletedAt = false
 	t.IsIgnored = false
 	t.IsArray = false
+	t.IsTenant = false
+	t.IsEncrypted = false
+	t.BlindIndex = ""
 	t.Value = nil
 	t.IsNil = false
 	t.IsZero = false
 	t.Index = 0
 }
 
+// ForeignReference referenced table and columns from foreign key
+// Supports "schema.table.column" and "schema.table(column1,column2)" formats
+func (t *ModelFiledTag) ForeignReference() (table string, columns []string) {
+	if t.ForeignKey == "" {
+		return
+	}
+	if i := strings.IndexByte(t.ForeignKey, '('); i > 0 && strings.HasSuffix(t.ForeignKey, ")") {
+		return t.ForeignKey[:i], strings.Split(t.ForeignKey[i+1:len(t.ForeignKey)-1], ",")
+	}
+	i := strings.LastIndexByte(t.ForeignKey, '.')
+	if i <= 0 {
+		return
+	}
+	return t.ForeignKey[:i], []string{t.ForeignKey[i+1:]}
+}
+
 // Prepare string tag
 func (t *ModelFiledTag) String() string {
 	b := strings.Builder{}
@@ -132,6 +206,15 @@ func (t *ModelFiledTag) String() string {
 	if t.IsArray {
 		b.WriteString("arr;")
 	}
+	if t.IsTenant {
+		b.WriteString("tnt;")
+	}
+	if t.IsEncrypted {
+		b.WriteString("enc;")
+	}
+	if t.BlindIndex != "" {
+		b.WriteString("bix~" + t.BlindIndex + ";")
+	}
 	return b.String()
 }
 
@@ -182,6 +265,14 @@ func ParseModelFiledTag(tag string, field *ModelFiledTag) {
 				field.IsIgnored = true
 				i++
 				indexStart = i
+			case "tnt":
+				field.IsTenant = true
+				i++
+				indexStart = i
+			case "enc":
+				field.IsEncrypted = true
+				i++
+				indexStart = i
 			case "col":
 				// Must be ~ according to format
 				if tag[indexStart+3] != '~' {
@@ -198,6 +289,14 @@ func ParseModelFiledTag(tag string, field *ModelFiledTag) {
 				field.ForeignKey = tag[indexStart+4 : i]
 				i++
 				indexStart = i
+			case "bix":
+				// Must be ~ according to format
+				if tag[indexStart+3] != '~' {
+					break
+				}
+				field.BlindIndex = tag[indexStart+4 : i]
+				i++
+				indexStart = i
 			}
 		}
 		i++
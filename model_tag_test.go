@@ -61,6 +61,32 @@ func TestParseModelFiledTag(t *testing.T) {
 			t.Fatal("Wrong parser column name")
 		}
 	})
+	t.Run("tenant", func(t *testing.T) {
+		tag := "col~tenant_id;tnt;req;"
+		var field ModelFiledTag
+		ParseModelFiledTag(tag, &field)
+		if field.Column != "tenant_id" || !field.IsTenant || !field.IsRequired {
+			t.Fatal("Wrong tenant tag")
+		}
+		if len(field.String()) != len(tag) {
+			t.Fatal("Wrong tenant tag string: " + field.String())
+		}
+	})
+	t.Run("encrypted", func(t *testing.T) {
+		var field ModelFiledTag
+		ParseModelFiledTag("col~email;enc;", &field)
+		if field.Column != "email" || !field.IsEncrypted {
+			t.Fatal("Wrong enc tag")
+		}
+		field.Clear()
+		ParseModelFiledTag("col~email_index;unq;bix~email;", &field)
+		if field.Column != "email_index" || field.BlindIndex != "email" || !field.IsUnique {
+			t.Fatal("Wrong bix tag")
+		}
+		if field.String() != "col~email_index;unq;bix~email;" {
+			t.Fatal("Wrong bix tag string: " + field.String())
+		}
+	})
 	t.Run("wrong_frk", func(t *testing.T) {
 		tag := "frk;aaa"
 		var field ModelFiledTag
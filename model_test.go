@@ -2,8 +2,12 @@ package gomodel
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"github.com/dimonrus/godb/v2"
 	"github.com/dimonrus/gohelp"
+	"github.com/dimonrus/gosql"
 	"github.com/lib/pq"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -256,3 +260,56 @@ func (m *ComplexTestModel) Columns() []string {
 func (m *ComplexTestModel) Values() []any {
 	return []any{pq.Array(&m.Pages), &m.Name, &m.ComplexId, &m.CategoryId, &m.SomeInt, &m.CreatedAt, &m.Custom}
 }
+
+// affectedQueryer queryer with fixed count of affected rows
+type affectedQueryer struct {
+	godb.Queryer
+	affected int64
+}
+
+// Exec query
+func (q affectedQueryer) Exec(query string, args ...any) (sql.Result, error) {
+	return driver.RowsAffected(q.affected), nil
+}
+
+func TestDo_RowsAffected(t *testing.T) {
+	id := 1
+	model := &DeleteModel1{Id: &id}
+	t.Run("delete_not_found", func(t *testing.T) {
+		e := Do(affectedQueryer{affected: 0}, GetDeleteSQL(model))
+		if e == nil || e.GetHTTP() != http.StatusNotFound {
+			t.Fatal("delete without affected rows must be not found")
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		if e := Do(affectedQueryer{affected: 1}, GetDeleteSQL(model)); e != nil {
+			t.Fatal(e)
+		}
+		// cached query
+		e := Do(affectedQueryer{affected: 0}, GetDeleteSQL(model))
+		if e == nil || e.GetHTTP() != http.StatusNotFound {
+			t.Fatal("cached delete without affected rows must be not found")
+		}
+	})
+	t.Run("insert", func(t *testing.T) {
+		insert := gosql.NewInsert().Into("test_model")
+		insert.Columns().Arg("id", 1)
+		if e := Do(affectedQueryer{affected: 0}, insert); e != nil {
+			t.Fatal("insert must not check affected rows")
+		}
+	})
+	t.Run("exactly_one", func(t *testing.T) {
+		update := gosql.NewUpdate().Table("test_model")
+		update.Set().Add("name = ?", "name")
+		if e := Do(affectedQueryer{affected: 2}, update); e != nil {
+			t.Fatal(e)
+		}
+		e := DoWithOptions(affectedQueryer{affected: 2}, update, DoOptions{ExactlyOne: true})
+		if e == nil || e.GetHTTP() != http.StatusConflict {
+			t.Fatal("update of several rows must be conflict")
+		}
+		if e = DoWithOptions(affectedQueryer{affected: 1}, update, DoOptions{ExactlyOne: true}); e != nil {
+			t.Fatal(e)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package gomodel
 
 import (
 	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
 	"github.com/lib/pq"
 	"reflect"
 )
@@ -10,9 +11,31 @@ import (
 // model - target model
 // fields - list of fields that you want to update
 func GetUpdateSQL(model IModel, fields ...any) gosql.ISQL {
-	isql := IndexCache.Get(IndexOperationUpdate, model, fields...)
+	isql, _ := getUpdateSQL(IndexCache, model, fields...)
+	return isql
+}
+
+// getUpdateSQL model update query. Blind index error is returned
+// ic - query cache
+func getUpdateSQL(ic *cache, model IModel, fields ...any) (gosql.ISQL, porterr.IError) {
+	if !isTenantSet(model) {
+		return nil, errorEmptySQL()
+	}
+	if e := prepareBlindIndex(model); e != nil {
+		return nil, e
+	}
+	isql := updateSQL(ic, model, fields...)
+	if isql == nil {
+		return nil, errorEmptySQL()
+	}
+	return isql, nil
+}
+
+// updateSQL model update query with prepared blind index
+func updateSQL(ic *cache, model IModel, fields ...any) gosql.ISQL {
+	isql := ic.Get(IndexOperationUpdate, model, fields...)
 	if isql != nil {
-		return isql
+		return encryptSQL(model, isql)
 	}
 	meta := PrepareMetaModel(model)
 	if meta == nil {
@@ -26,8 +49,13 @@ func GetUpdateSQL(model IModel, fields ...any) gosql.ISQL {
 	var hasPrimaryKey bool
 	var condition = gosql.NewSqlCondition(gosql.ConditionOperatorAnd)
 	var update = gosql.NewUpdate()
+	var tenantPos = -1
 	for i := 0; i < meta.Fields.Len(); i++ {
 		tField := meta.Fields[i]
+		if tField.IsTenant {
+			tenantPos = i
+			continue
+		}
 		for _, v := range fields {
 			cte := reflect.ValueOf(v)
 			if cte.Kind() != reflect.Ptr {
@@ -62,7 +90,11 @@ func GetUpdateSQL(model IModel, fields ...any) gosql.ISQL {
 							update.Set().Append(tField.Column+" = ?", tField.Value)
 							idx.AppendParamPos(int16(i))
 						} else {
-							update.Set().Append(tField.Column + " = NOW()")
+							expr, args := setNow(tField.Column)
+							update.Set().Append(expr, args...)
+							if len(args) > 0 {
+								idx.AppendParamPos(clockPos)
+							}
 						}
 						update.Returning().Append(tField.Column, tField.Value)
 						idx.AppendReturningPos(int16(i))
@@ -95,12 +127,16 @@ func GetUpdateSQL(model IModel, fields ...any) gosql.ISQL {
 	if update.IsEmpty() && condition.IsEmpty() {
 		return nil
 	}
+	if tenantPos >= 0 {
+		condition.AddExpression(meta.Fields[tenantPos].Column+" = ?", meta.Fields[tenantPos].Value)
+		conditionParams = append(conditionParams, int16(tenantPos))
+	}
 	update.Table(model.Table())
 	if !condition.IsEmpty() {
 		update.Where().Replace(condition)
 	}
 	idx.SetQuery(update.String())
 	idx.AppendParamPos(conditionParams...)
-	IndexCache.Store(IndexCache.Key(IndexOperationUpdate, model.Table(), model.Columns(), model.Values(), fields...), idx)
-	return update
+	ic.Store(IndexCache.Key(IndexOperationUpdate, model.Table(), model.Columns(), model.Values(), fields...), idx)
+	return encryptSQL(model, update)
 }
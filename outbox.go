@@ -0,0 +1,271 @@
+package gomodel
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+const (
+	// OutboxTableName default outbox table name
+	OutboxTableName = "outbox"
+	// RelayDefaultBatchSize default count of events per poll
+	RelayDefaultBatchSize = 100
+	// RelayDefaultInterval default delay between polls when outbox is empty
+	RelayDefaultInterval = time.Second
+)
+
+// EventSource model produce own outbox events
+type EventSource interface {
+	// OutboxEvents events for operation. io - IndexOperationSave or IndexOperationDelete
+	OutboxEvents(io IndexOperation) ([]*OutboxEvent, error)
+}
+
+// OutboxEvent outbox table model
+type OutboxEvent struct {
+	// Event identifier
+	Id *int64 `json:"id" db:"col~id;prk;req;seq;"`
+	// Event topic
+	Topic *string `json:"topic" db:"col~topic;req;"`
+	// Event type
+	Type *string `json:"type" db:"col~type;req;"`
+	// Event key. Primary key of model by default
+	Key *string `json:"key" db:"col~key;"`
+	// Event payload in json format
+	Payload *string `json:"payload" db:"col~payload;req;"`
+	// Event created time
+	CreatedAt *time.Time `json:"createdAt" db:"col~created_at;cat;"`
+	// Event delivered time
+	DeliveredAt *time.Time `json:"deliveredAt" db:"col~delivered_at;"`
+}
+
+// Table get OutboxEvent model table name
+func (m *OutboxEvent) Table() string {
+	return Outbox.TableName
+}
+
+// Columns get all OutboxEvent model columns
+func (m *OutboxEvent) Columns() []string {
+	return []string{"id", "topic", "type", "key", "payload", "created_at", "delivered_at"}
+}
+
+// Values get all OutboxEvent model values
+func (m *OutboxEvent) Values() []any {
+	return []any{&m.Id, &m.Topic, &m.Type, &m.Key, &m.Payload, &m.CreatedAt, &m.DeliveredAt}
+}
+
+// NewOutboxEvent init OutboxEvent model
+// payload - any value encoded to json
+func NewOutboxEvent(topic, eventType, key string, payload any) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	p := string(data)
+	return &OutboxEvent{Topic: &topic, Type: &eventType, Key: &key, Payload: &p}, nil
+}
+
+// Outbox outbox registry
+var Outbox = &outbox{
+	TableName: OutboxTableName,
+	tables:    make(map[string]struct{}, IndexCacheDefaultLength),
+}
+
+// outbox type
+type outbox struct {
+	// TableName outbox table name
+	TableName string
+	// list of registered tables
+	tables map[string]struct{}
+	// rw mutex
+	m sync.RWMutex
+}
+
+// Register models for outbox events
+// Event topic is a model table, type is an operation, payload is a model in json
+func (o *outbox) Register(model ...IModel) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	for i := range model {
+		o.tables[model[i].Table()] = struct{}{}
+	}
+}
+
+// Unregister models from outbox events
+func (o *outbox) Unregister(model ...IModel) {
+	o.m.Lock()
+	defer o.m.Unlock()
+	for i := range model {
+		delete(o.tables, model[i].Table())
+	}
+}
+
+// IsRegistered check if model registered for outbox events
+func (o *outbox) IsRegistered(model IModel) bool {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	_, ok := o.tables[model.Table()]
+	return ok
+}
+
+// Events prepare outbox events for model operation
+func (o *outbox) Events(model IModel, io IndexOperation) ([]*OutboxEvent, porterr.IError) {
+	if source, ok := model.(EventSource); ok {
+		events, err := source.OutboxEvents(io)
+		if err != nil {
+			return nil, porterr.New(porterr.PortErrorProducer, "Outbox events error: "+err.Error())
+		}
+		return events, nil
+	}
+	if !o.IsRegistered(model) {
+		return nil, nil
+	}
+	key, err := keyJSON(model)
+	if err != nil {
+		return nil, porterr.New(porterr.PortErrorEncoder, "Outbox event key error: "+err.Error())
+	}
+	event, err := NewOutboxEvent(model.Table(), string(io), string(key), model)
+	if err != nil {
+		return nil, porterr.New(porterr.PortErrorEncoder, "Outbox event payload error: "+err.Error())
+	}
+	return []*OutboxEvent{event}, nil
+}
+
+// emit insert outbox events for model operation
+// q must be a transaction to save events atomically with model
+func (o *outbox) emit(q godb.Queryer, model IModel, io IndexOperation) porterr.IError {
+	events, e := o.Events(model, io)
+	if e != nil {
+		return e
+	}
+	for i := range events {
+		e = Do(q, GetInsertSQL(events[i], &events[i].Id, &events[i].Topic, &events[i].Type, &events[i].Key, &events[i].Payload, &events[i].CreatedAt))
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// OutboxTable init outbox table
+func OutboxTable(name string) gosql.SQList {
+	table := gosql.CreateTable(name)
+	gosql.TableModeler{BigSerialPrimaryKeyModifier, OutboxModifier}.Prepare(table)
+	index := gosql.CreateIndex(name, "id").Name(name + "_undelivered_idx").IfNotExists()
+	index.Where().AddExpression("delivered_at IS NULL")
+	return gosql.SQList{table, index}
+}
+
+// OutboxModifier create outbox columns
+func OutboxModifier(tb *gosql.Table) {
+	tb.AddColumn("topic").Type("TEXT").Constraint().NotNull()
+	tb.AddColumn("type").Type("TEXT").Constraint().NotNull()
+	tb.AddColumn("key").Type("TEXT")
+	tb.AddColumn("payload").Type("JSONB").Constraint().NotNull()
+	tb.AddColumn("created_at").Type("TIMESTAMP WITH TIME ZONE").Constraint().NotNull().Default("CURRENT_TIMESTAMP")
+	tb.AddColumn("delivered_at").Type("TIMESTAMP WITH TIME ZONE")
+}
+
+// Relay deliver outbox events to publisher
+type Relay struct {
+	// database connection. Must be *godb.DBO or *Router
+	db godb.Queryer
+	// publisher callback
+	publisher func(event *OutboxEvent) error
+	// BatchSize count of events per poll
+	BatchSize int
+	// Interval delay between polls when outbox is empty
+	Interval time.Duration
+}
+
+// pollSQL query for undelivered events
+func (r *Relay) pollSQL() string {
+	c := NewCollection[OutboxEvent]()
+	c.Where().AddExpression("delivered_at IS NULL")
+	c.AddOrder("id")
+	c.SetPagination(r.BatchSize, 0)
+	return c.String() + " FOR UPDATE SKIP LOCKED"
+}
+
+// Poll deliver one batch of events
+// Events are locked with FOR UPDATE SKIP LOCKED so several relays can work concurrently.
+// Publishing stops on first publisher error. Already published events are marked as delivered
+// Publisher error is returned with count of delivered events
+func (r *Relay) Poll() (count int, e porterr.IError) {
+	var pe porterr.IError
+	e = InTx(r.db, func(tx godb.Queryer) porterr.IError {
+		count = 0
+		rows, err := tx.Query(r.pollSQL())
+		if err != nil {
+			return NewDatabaseError(err)
+		}
+		c := NewCollection[OutboxEvent]()
+		e := c.scan(rows)
+		_ = rows.Close()
+		if e != nil {
+			return e
+		}
+		var ids = make([]int64, 0, c.Count())
+		pe = nil
+		for c.Next() {
+			if err = r.publisher(c.Item()); err != nil {
+				pe = porterr.New(porterr.PortErrorProducer, "Outbox event publish error: "+err.Error())
+				break
+			}
+			ids = append(ids, *c.Item().Id)
+		}
+		if len(ids) > 0 {
+			update := gosql.NewUpdate().Table(Outbox.TableName)
+			expr, args := setNow("delivered_at")
+			update.Set().Append(expr, args...)
+			update.Where().AddExpression("id = ANY(?)", pq.Array(ids))
+			if e = Do(tx, update); e != nil {
+				return e
+			}
+			count = len(ids)
+		}
+		return nil
+	}, TxOptions{})
+	if e == nil {
+		e = pe
+	}
+	return
+}
+
+// Run poll events until context is done
+// onError - optional callback for poll errors
+func (r *Relay) Run(ctx context.Context, onError func(e porterr.IError)) {
+	for {
+		count, e := r.Poll()
+		if e != nil && onError != nil {
+			onError(e)
+		}
+		if count < r.BatchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.Interval):
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// NewRelay init outbox relay
+// db - *godb.DBO or *Router
+// publisher - deliver event to broker
+func NewRelay(db godb.Queryer, publisher func(event *OutboxEvent) error) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		BatchSize: RelayDefaultBatchSize,
+		Interval:  RelayDefaultInterval,
+	}
+}
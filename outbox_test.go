@@ -0,0 +1,118 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dimonrus/porterr"
+)
+
+type EventSourceModel struct {
+	InsertModel1
+}
+
+func (m *EventSourceModel) OutboxEvents(io IndexOperation) ([]*OutboxEvent, error) {
+	if io == IndexOperationDelete {
+		return nil, errors.New("delete is not allowed")
+	}
+	event, err := NewOutboxEvent("models", "model_saved", "custom", map[string]any{"id": m.Id})
+	return []*OutboxEvent{event}, err
+}
+
+func TestOutboxTable(t *testing.T) {
+	query, _, _ := OutboxTable(OutboxTableName).Join()
+	t.Log(query)
+	if query != "CREATE TABLE outbox (id bigserial NOT NULL PRIMARY KEY, topic TEXT NOT NULL, type TEXT NOT NULL, key TEXT, payload JSONB NOT NULL, created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, delivered_at TIMESTAMP WITH TIME ZONE);CREATE INDEX IF NOT EXISTS outbox_undelivered_idx ON outbox (id) WHERE (delivered_at IS NULL);" {
+		t.Fatal("wrong outbox table query")
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	t.Run("registered", func(t *testing.T) {
+		m := &InsertModel1{Id: &ACMId, Name: &ACMName}
+		events, e := Outbox.Events(m, IndexOperationSave)
+		if e != nil || len(events) != 0 {
+			t.Fatal("not registered model must be without events")
+		}
+		Outbox.Register(m)
+		defer Outbox.Unregister(m)
+		events, e = Outbox.Events(m, IndexOperationSave)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if len(events) != 1 {
+			t.Fatal("wrong events len")
+		}
+		if *events[0].Topic != "test_model_1" || *events[0].Type != "save" || *events[0].Key != `{"id":10}` {
+			t.Fatal("wrong event")
+		}
+		if *events[0].Payload != `{"id":10,"name":"Foo","pages":null,"someInt":null,"createdAt":null,"updatedAt":null,"deletedAt":null}` {
+			t.Fatal("wrong event payload: " + *events[0].Payload)
+		}
+	})
+	t.Run("event_source", func(t *testing.T) {
+		m := &EventSourceModel{}
+		m.Id = &ACMId
+		events, e := Outbox.Events(m, IndexOperationSave)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if len(events) != 1 || *events[0].Key != "custom" || *events[0].Payload != `{"id":10}` {
+			t.Fatal("wrong event source events")
+		}
+		_, e = Outbox.Events(m, IndexOperationDelete)
+		if e == nil {
+			t.Fatal("must be an error")
+		}
+	})
+	t.Run("insert", func(t *testing.T) {
+		event, _ := NewOutboxEvent("models", "model_saved", "1", nil)
+		query, params, returning := GetInsertSQL(event, &event.Id, &event.Topic, &event.Type, &event.Key, &event.Payload, &event.CreatedAt).SQL()
+		t.Log(query)
+		if query != "INSERT INTO outbox (topic, type, key, payload) VALUES (?, ?, ?, ?) RETURNING id, created_at;" {
+			t.Fatal("wrong insert query")
+		}
+		if len(params) != 4 || len(returning) != 2 {
+			t.Fatal("wrong insert params")
+		}
+	})
+}
+
+func TestRelay(t *testing.T) {
+	r := NewRelay(nil, func(event *OutboxEvent) error { return nil })
+	r.BatchSize = 10
+	query := r.pollSQL()
+	t.Log(query)
+	if query != "SELECT id, topic, type, key, payload, created_at, delivered_at FROM outbox WHERE (delivered_at IS NULL) ORDER BY id LIMIT 10 OFFSET 0 FOR UPDATE SKIP LOCKED" {
+		t.Fatal("wrong poll query")
+	}
+}
+
+func TestRelay_PollPublisherError(t *testing.T) {
+	db, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+		if strings.HasPrefix(query, "SELECT") {
+			columns := []string{"id", "topic", "type", "key", "payload", "created_at", "delivered_at"}
+			return fakeResult{columns: columns, rows: [][]driver.Value{
+				{int64(1), "t", "save", "k", "{}", nil, nil},
+				{int64(2), "t", "save", "k", "{}", nil, nil},
+			}}
+		}
+		return fakeResult{affected: 1}
+	})
+	r := NewRelay(db, func(event *OutboxEvent) error {
+		if *event.Id == 2 {
+			return errors.New("broker is down")
+		}
+		return nil
+	})
+	count, e := r.Poll()
+	if count != 1 || e == nil || e.GetCode() != porterr.PortErrorProducer {
+		t.Fatal("publisher error must be returned with count of delivered events")
+	}
+	log := fake.log()
+	if !strings.HasPrefix(log[2], "UPDATE outbox SET delivered_at = NOW()") || log[3] != "COMMIT" {
+		t.Fatal("delivered events must be committed: " + strings.Join(log, "; "))
+	}
+}
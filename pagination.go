@@ -0,0 +1,266 @@
+package gomodel
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/dimonrus/porterr"
+)
+
+// Cursor opaque signed token of page position. Empty cursor is the first page
+type Cursor string
+
+// OrderField order column for keyset pagination
+type OrderField struct {
+	// Column name
+	Column string
+	// Descending order
+	Desc bool
+}
+
+// cursor signing key
+var cursorKey struct {
+	key []byte
+	m   sync.RWMutex
+}
+
+// SetCursorKey set key for cursor signature
+func SetCursorKey(key []byte) {
+	cursorKey.m.Lock()
+	defer cursorKey.m.Unlock()
+	cursorKey.key = key
+}
+
+// cursorPayload cursor content
+type cursorPayload struct {
+	// Page before position
+	Backward bool `json:"b,omitempty"`
+	// Values of order columns
+	Values []json.RawMessage `json:"v"`
+}
+
+// sign cursor payload
+func signCursor(payload []byte) ([]byte, porterr.IError) {
+	cursorKey.m.RLock()
+	defer cursorKey.m.RUnlock()
+	if cursorKey.key == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Cursor key is not set")
+	}
+	mac := hmac.New(sha256.New, cursorKey.key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// encodeCursor prepare signed cursor
+func encodeCursor(payload cursorPayload) (Cursor, porterr.IError) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", porterr.New(porterr.PortErrorEncoder, "Cursor encode error: "+err.Error())
+	}
+	signature, e := signCursor(data)
+	if e != nil {
+		return "", e
+	}
+	return Cursor(base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+// decodeCursor check signature and decode cursor
+func decodeCursor(cursor Cursor) (payload cursorPayload, e porterr.IError) {
+	e = porterr.New(porterr.PortErrorArgument, "Cursor is not valid").HTTP(http.StatusBadRequest)
+	i := strings.IndexByte(string(cursor), '.')
+	if i < 0 {
+		return
+	}
+	data, err := base64.RawURLEncoding.DecodeString(string(cursor[:i]))
+	if err != nil {
+		return
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(string(cursor[i+1:]))
+	if err != nil {
+		return
+	}
+	expected, se := signCursor(data)
+	if se != nil {
+		return payload, se
+	}
+	if !hmac.Equal(signature, expected) {
+		return
+	}
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return
+	}
+	return payload, nil
+}
+
+// keyset pagination state of collection
+type keyset struct {
+	// order columns with primary key columns
+	order []OrderField
+	// positions of order columns in model values
+	positions []int
+	// page size
+	limit int
+	// page before cursor
+	backward bool
+	// page requested by cursor
+	hasCursor bool
+	// more rows exist in direction of pagination
+	hasMore bool
+}
+
+// condition keyset condition for cursor values
+// Example: ((a > ?) OR (a = ? AND id > ?))
+func (k *keyset) condition(values []any) (string, []any) {
+	var b strings.Builder
+	var args = make([]any, 0, len(values)*(len(values)+1)/2)
+	b.WriteString("(")
+	for i := range k.order {
+		if i > 0 {
+			b.WriteString(" OR ")
+		}
+		b.WriteString("(")
+		for j := 0; j < i; j++ {
+			b.WriteString(k.order[j].Column + " = ? AND ")
+			args = append(args, values[j])
+		}
+		if k.order[i].Desc != k.backward {
+			b.WriteString(k.order[i].Column + " < ?")
+		} else {
+			b.WriteString(k.order[i].Column + " > ?")
+		}
+		args = append(args, values[i])
+		b.WriteString(")")
+	}
+	b.WriteString(")")
+	return b.String(), args
+}
+
+// cursor prepare cursor from model values
+func (k *keyset) cursor(model IModel, backward bool) (Cursor, porterr.IError) {
+	values := model.Values()
+	payload := cursorPayload{Backward: backward, Values: make([]json.RawMessage, len(k.positions))}
+	for i, pos := range k.positions {
+		v := reflect.ValueOf(values[pos])
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		var value any
+		if v.Kind() != reflect.Ptr {
+			value = v.Interface()
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", porterr.New(porterr.PortErrorEncoder, "Cursor encode error: "+err.Error())
+		}
+		payload.Values[i] = data
+	}
+	return encodeCursor(payload)
+}
+
+// Paginate prepare keyset pagination query
+// after - cursor from NextCursor or PrevCursor. Empty for the first page
+// limit - page size
+// order - order columns. Primary key columns are added to make order stable
+// Order columns must be not null. Use NextCursor and PrevCursor after Load
+func (c *Collection[T]) Paginate(after Cursor, limit int, order ...OrderField) porterr.IError {
+	if limit <= 0 {
+		return porterr.New(porterr.PortErrorArgument, "Limit must be greater than 0")
+	}
+	var item interface{} = new(T)
+	meta := PrepareMetaModel(item.(IModel))
+	k := &keyset{limit: limit, order: make([]OrderField, 0, len(order)+1)}
+	k.order = append(k.order, order...)
+	for i := range meta.Fields {
+		if meta.Fields[i].IsPrimaryKey {
+			var exists bool
+			for j := range order {
+				exists = exists || order[j].Column == meta.Fields[i].Column
+			}
+			if !exists {
+				k.order = append(k.order, OrderField{Column: meta.Fields[i].Column})
+			}
+		}
+	}
+	k.positions = make([]int, len(k.order))
+	for i := range k.order {
+		k.positions[i] = columnPosition(meta.Fields, k.order[i].Column)
+		if k.positions[i] < 0 {
+			return porterr.New(porterr.PortErrorArgument, "Order column "+k.order[i].Column+" is not found in "+meta.TableName)
+		}
+	}
+	if after != "" {
+		payload, e := decodeCursor(after)
+		if e != nil {
+			return e
+		}
+		if len(payload.Values) != len(k.order) {
+			return porterr.New(porterr.PortErrorArgument, "Cursor does not match order").HTTP(http.StatusBadRequest)
+		}
+		values := make([]any, len(payload.Values))
+		for i := range payload.Values {
+			decoder := json.NewDecoder(bytes.NewReader(payload.Values[i]))
+			decoder.UseNumber()
+			if err := decoder.Decode(&values[i]); err != nil {
+				return porterr.New(porterr.PortErrorArgument, "Cursor is not valid").HTTP(http.StatusBadRequest)
+			}
+		}
+		k.backward = payload.Backward
+		k.hasCursor = true
+		condition, args := k.condition(values)
+		c.Where().AddExpression(condition, args...)
+	}
+	c.ResetOrder()
+	for i := range k.order {
+		if k.order[i].Desc != k.backward {
+			c.AddOrder(k.order[i].Column + " DESC")
+		} else {
+			c.AddOrder(k.order[i].Column)
+		}
+	}
+	c.SetPagination(limit+1, 0)
+	c.keyset = k
+	return nil
+}
+
+// paginate trim extra row and restore order of loaded page
+func (c *Collection[T]) paginate() {
+	k := c.keyset
+	if k == nil {
+		return
+	}
+	k.hasMore = len(c.items) > k.limit
+	if k.hasMore {
+		c.items = c.items[:k.limit]
+	}
+	if k.backward {
+		for i, j := 0, len(c.items)-1; i < j; i, j = i+1, j-1 {
+			c.items[i], c.items[j] = c.items[j], c.items[i]
+		}
+	}
+	c.SetCount(len(c.items))
+}
+
+// NextCursor cursor of the next page. Empty if there is no next page
+func (c *Collection[T]) NextCursor() (Cursor, porterr.IError) {
+	k := c.keyset
+	if k == nil || c.Last() == nil || (!k.backward && !k.hasMore) {
+		return "", nil
+	}
+	return k.cursor((interface{})(c.Last()).(IModel), false)
+}
+
+// PrevCursor cursor of the previous page. Empty if there is no previous page
+func (c *Collection[T]) PrevCursor() (Cursor, porterr.IError) {
+	k := c.keyset
+	if k == nil || c.First() == nil || (k.backward && !k.hasMore) || (!k.backward && !k.hasCursor) {
+		return "", nil
+	}
+	return k.cursor((interface{})(c.First()).(IModel), true)
+}
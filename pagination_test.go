@@ -0,0 +1,113 @@
+package gomodel
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	SetCursorKey([]byte("secret"))
+	defer SetCursorKey(nil)
+	t.Run("first_page", func(t *testing.T) {
+		c := NewCollection[InsertModel1]()
+		if e := c.Paginate("", 2, OrderField{Column: "some_int", Desc: true}); e != nil {
+			t.Fatal(e)
+		}
+		t.Log(c.String())
+		if c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 ORDER BY some_int DESC, id LIMIT 3 OFFSET 0" {
+			t.Fatal("wrong first page query")
+		}
+		for _, v := range []int{30, 20, 10} {
+			id, some := v/10, v
+			c.AddItem(&InsertModel1{Id: &id, SomeInt: &some})
+		}
+		c.paginate()
+		if c.Count() != 2 || *c.Last().Id != 2 {
+			t.Fatal("wrong page items")
+		}
+		if prev, _ := c.PrevCursor(); prev != "" {
+			t.Fatal("first page must be without previous cursor")
+		}
+		next, e := c.NextCursor()
+		if e != nil || next == "" {
+			t.Fatal("next cursor must exist")
+		}
+		t.Run("next_page", func(t *testing.T) {
+			c := NewCollection[InsertModel1]()
+			if e := c.Paginate(next, 2, OrderField{Column: "some_int", Desc: true}); e != nil {
+				t.Fatal(e)
+			}
+			t.Log(c.String())
+			if c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (((some_int < ?) OR (some_int = ? AND id > ?))) ORDER BY some_int DESC, id LIMIT 3 OFFSET 0" {
+				t.Fatal("wrong next page query")
+			}
+			args := c.GetArguments()
+			if len(args) != 3 || args[0].(interface{ String() string }).String() != "20" || args[2].(interface{ String() string }).String() != "2" {
+				t.Fatal("wrong next page arguments")
+			}
+			id, some := 1, 10
+			c.AddItem(&InsertModel1{Id: &id, SomeInt: &some})
+			c.paginate()
+			if next, _ := c.NextCursor(); next != "" {
+				t.Fatal("last page must be without next cursor")
+			}
+			prev, e := c.PrevCursor()
+			if e != nil || prev == "" {
+				t.Fatal("previous cursor must exist")
+			}
+			t.Run("prev_page", func(t *testing.T) {
+				c := NewCollection[InsertModel1]()
+				if e := c.Paginate(prev, 2, OrderField{Column: "some_int", Desc: true}); e != nil {
+					t.Fatal(e)
+				}
+				t.Log(c.String())
+				if c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (((some_int > ?) OR (some_int = ? AND id < ?))) ORDER BY some_int, id DESC LIMIT 3 OFFSET 0" {
+					t.Fatal("wrong previous page query")
+				}
+				for _, v := range []int{20, 30} {
+					id, some := v/10, v
+					c.AddItem(&InsertModel1{Id: &id, SomeInt: &some})
+				}
+				c.paginate()
+				if *c.First().Id != 3 || *c.Last().Id != 2 {
+					t.Fatal("previous page must be in original order")
+				}
+				if prev, _ := c.PrevCursor(); prev != "" {
+					t.Fatal("first page must be without previous cursor")
+				}
+				if next, _ := c.NextCursor(); next == "" {
+					t.Fatal("next cursor must exist")
+				}
+			})
+		})
+	})
+	t.Run("tampered", func(t *testing.T) {
+		c := NewCollection[InsertModel1]()
+		id := 1
+		c.AddItem(&InsertModel1{Id: &id})
+		_ = c.Paginate("", 1)
+		c.AddItem(&InsertModel1{Id: &id})
+		c.paginate()
+		cursor, _ := c.NextCursor()
+		i := strings.IndexByte(string(cursor), '.')
+		tampered := Cursor(base64.RawURLEncoding.EncodeToString([]byte(`{"v":[2]}`))) + cursor[i:]
+		if e := NewCollection[InsertModel1]().Paginate(tampered, 1); e == nil || e.GetHTTP() != 400 {
+			t.Fatal("tampered cursor must be rejected")
+		}
+		if e := NewCollection[InsertModel1]().Paginate(cursor, 1); e != nil {
+			t.Fatal(e)
+		}
+		if e := NewCollection[InsertModel1]().Paginate(cursor, 1, OrderField{Column: "name"}); e == nil {
+			t.Fatal("cursor of another order must be rejected")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		if e := NewCollection[InsertModel1]().Paginate("", 0); e == nil {
+			t.Fatal("must be limit error")
+		}
+		if e := NewCollection[InsertModel1]().Paginate("", 1, OrderField{Column: "unknown"}); e == nil {
+			t.Fatal("must be column error")
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package gomodel
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+const (
+	// WorkerDefaultBatchSize default count of claimed items per poll
+	WorkerDefaultBatchSize = 10
+	// WorkerDefaultConcurrency default count of concurrently processed items
+	WorkerDefaultConcurrency = 4
+	// WorkerDefaultLease default lease of claimed items
+	WorkerDefaultLease = time.Minute
+	// WorkerDefaultInterval default delay between polls when queue is empty
+	WorkerDefaultInterval = time.Second
+)
+
+// QueueOptions columns of table used as a work queue
+// At least one of StatusColumn or LeaseColumn must be set
+type QueueOptions struct {
+	// StatusColumn status column
+	StatusColumn string
+	// PendingStatus status of rows ready to be claimed and of released rows
+	PendingStatus any
+	// ClaimedStatus status of claimed rows
+	ClaimedStatus any
+	// CompletedStatus status of completed rows
+	CompletedStatus any
+	// LeaseColumn timestamp column. Claimed rows are leased until NOW() + lease
+	// Rows with expired lease can be claimed again
+	LeaseColumn string
+}
+
+// WithQueue use collection as a work queue for Claim, Release and Complete
+func (c *Collection[T]) WithQueue(options QueueOptions) *Collection[T] {
+	c.queue = &options
+	return c
+}
+
+// queueKey primary key column of queue model
+func (c *Collection[T]) queueKey() (*MetaModel, *ModelFiledTag, porterr.IError) {
+	if c.queue == nil || (c.queue.StatusColumn == "" && c.queue.LeaseColumn == "") {
+		return nil, nil, porterr.New(porterr.PortErrorArgument, "Queue options are not set")
+	}
+	var item interface{} = c.Model()
+	meta := PrepareMetaModel(item.(IModel))
+	keys := meta.Fields.Keys()
+	if len(keys) != 1 {
+		return nil, nil, porterr.New(porterr.PortErrorArgument, "Queue model "+meta.TableName+" must have one primary key column")
+	}
+	return meta, &keys[0], nil
+}
+
+// queueSet add status, lease and updated at expressions
+// lease - lease in milliseconds. Negative value resets lease
+func (c *Collection[T]) queueSet(meta *MetaModel, update *gosql.Update, status any, lease int64) {
+	if c.queue.StatusColumn != "" && status != nil {
+		update.Set().Append(c.queue.StatusColumn+" = ?", status)
+	}
+	if c.queue.LeaseColumn != "" {
+		if lease < 0 {
+			update.Set().Append(c.queue.LeaseColumn + " = NULL")
+		} else {
+			update.Set().Append(c.queue.LeaseColumn+" = NOW() + ? * INTERVAL '1 millisecond'", lease)
+		}
+	}
+	for i := range meta.Fields {
+		if meta.Fields[i].IsUpdatedAt {
+			expr, args := setNow(meta.Fields[i].Column)
+			update.Set().Append(expr, args...)
+		}
+	}
+}
+
+// getClaimSQL claim query. Rows are selected by collection conditions and order with FOR UPDATE SKIP LOCKED
+func (c *Collection[T]) getClaimSQL(limit int, lease time.Duration) (*gosql.Update, porterr.IError) {
+	if e := c.checkTenant(); e != nil {
+		return nil, e
+	}
+	meta, key, e := c.queueKey()
+	if e != nil {
+		return nil, e
+	}
+	if limit <= 0 {
+		return nil, porterr.New(porterr.PortErrorArgument, "Limit must be greater than 0")
+	}
+	if !c.isRebuilt(false) {
+		return nil, errorNotRebuilt()
+	}
+	sub := c.derivedSelect()
+	sub.Columns().Add(meta.TableName + "." + key.Column)
+	sub.AddOrder(c.tracked.orders...)
+	sub.SetPagination(limit, 0)
+	var claimable []string
+	var args []any
+	if c.queue.StatusColumn != "" {
+		claimable = append(claimable, meta.TableName+"."+c.queue.StatusColumn+" = ?")
+		args = append(args, c.queue.PendingStatus)
+	}
+	if c.queue.LeaseColumn != "" {
+		lease := meta.TableName + "." + c.queue.LeaseColumn + " < NOW()"
+		if c.queue.StatusColumn != "" {
+			lease = "(" + meta.TableName + "." + c.queue.StatusColumn + " = ? AND " + lease + ")"
+			args = append(args, c.queue.ClaimedStatus)
+		} else {
+			claimable = append(claimable, meta.TableName+"."+c.queue.LeaseColumn+" IS NULL")
+		}
+		claimable = append(claimable, lease)
+	}
+	sub.Where().AddExpression("("+strings.Join(claimable, " OR ")+")", args...)
+	update := gosql.NewUpdate().Table(meta.TableName)
+	c.queueSet(meta, update, c.queue.ClaimedStatus, lease.Milliseconds())
+	update.Where().AddExpression(key.Column+" IN ("+sub.String()+" FOR UPDATE SKIP LOCKED)", sub.GetArguments()...)
+	var item interface{} = c.Model()
+	update.Returning().Add(item.(IModel).Columns()...)
+	return update, nil
+}
+
+// Claim lock rows matched by collection conditions, mark them as claimed and load them to collection
+// Rows locked by other transactions are skipped, so several workers can claim concurrently
+// limit - max count of claimed rows
+// lease - time to process claimed rows. Used if LeaseColumn is set
+func (c *Collection[T]) Claim(q godb.Queryer, limit int, lease time.Duration) (e porterr.IError) {
+	defer c.observe(IndexOperationUpdate, time.Now(), &e)
+	var update *gosql.Update
+	update, e = c.getClaimSQL(limit, lease)
+	if e != nil {
+		return
+	}
+	query, params, _ := update.SQL()
+	rows, err := q.Query(query, bindClock(q, params)...)
+	if err != nil {
+		return NewDatabaseError(err)
+	}
+	defer func() { _ = rows.Close() }()
+	claimed := NewCollection[T]()
+	if e = claimed.scan(rows); e != nil {
+		return
+	}
+	c.Clear()
+	c.AddItem(claimed.items...)
+	return
+}
+
+// getQueueUpdateSQL release or complete query for items
+// Rows are fenced by claimed status and by lease of items, so rows claimed again by other worker are not changed
+func (c *Collection[T]) getQueueUpdateSQL(status any, complete bool, items []*T) (gosql.ISQL, int, porterr.IError) {
+	if e := c.checkTenant(); e != nil {
+		return nil, 0, e
+	}
+	meta, key, e := c.queueKey()
+	if e != nil {
+		return nil, 0, e
+	}
+	if len(items) == 0 {
+		items = c.items
+	}
+	pos, leasePos := columnPosition(meta.Fields, key.Column), -1
+	if c.queue.LeaseColumn != "" {
+		if leasePos = columnPosition(meta.Fields, c.queue.LeaseColumn); leasePos < 0 {
+			return nil, 0, porterr.New(porterr.PortErrorArgument, "Lease column "+c.queue.LeaseColumn+" is not found in "+meta.TableName)
+		}
+	}
+	keys := make([]any, 0, len(items))
+	leases := make([]any, 0, len(items))
+	for _, item := range items {
+		values := (interface{})(item).(IModel).Values()
+		value, ok := relationKey(values[pos])
+		if !ok {
+			continue
+		}
+		keys = append(keys, value)
+		if leasePos >= 0 {
+			lease, ok := relationKey(values[leasePos])
+			if !ok {
+				return nil, 0, porterr.New(porterr.PortErrorArgument, "Lease of claimed item is not set")
+			}
+			leases = append(leases, lease)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, 0, nil
+	}
+	var isql gosql.ISQL
+	var where *gosql.Condition
+	if complete && c.queue.StatusColumn == "" {
+		del := gosql.NewDelete().From(meta.TableName)
+		isql, where = del, del.Where()
+	} else {
+		update := gosql.NewUpdate().Table(meta.TableName)
+		c.queueSet(meta, update, status, -1)
+		isql, where = update, update.Where()
+	}
+	where.AddExpression(key.Column+" = ANY(?)", pq.Array(keys))
+	if c.queue.StatusColumn != "" && c.queue.ClaimedStatus != nil {
+		where.AddExpression(c.queue.StatusColumn+" = ?", c.queue.ClaimedStatus)
+	}
+	if len(leases) > 0 {
+		where.AddExpression(c.queue.LeaseColumn+" = ANY(?)", pq.Array(leases))
+	}
+	return isql, len(keys), nil
+}
+
+// queueUpdate exec release or complete query
+// Returns conflict error if some items are not claimed anymore
+func (c *Collection[T]) queueUpdate(q godb.Queryer, status any, complete bool, items []*T) (e porterr.IError) {
+	defer c.observe(IndexOperationUpdate, time.Now(), &e)
+	var isql gosql.ISQL
+	var expected int
+	isql, expected, e = c.getQueueUpdateSQL(status, complete, items)
+	if e != nil || isql == nil {
+		return
+	}
+	var count int64
+	if count, e = execCount(q, isql); e != nil {
+		return
+	}
+	if count < int64(expected) {
+		e = porterr.New(porterr.PortErrorConflict, "Claim of "+strconv.FormatInt(int64(expected)-count, 10)+" items is lost").HTTP(http.StatusConflict)
+	}
+	return
+}
+
+// Release return claimed items to queue. Status is set to PendingStatus and lease is reset
+// items - claimed items. All collection items if empty
+func (c *Collection[T]) Release(q godb.Queryer, items ...*T) porterr.IError {
+	if c.queue == nil {
+		return porterr.New(porterr.PortErrorArgument, "Queue options are not set")
+	}
+	return c.queueUpdate(q, c.queue.PendingStatus, false, items)
+}
+
+// Complete mark claimed items as completed. Status is set to CompletedStatus and lease is reset
+// Rows are deleted if queue has no status column
+// items - claimed items. All collection items if empty
+func (c *Collection[T]) Complete(q godb.Queryer, items ...*T) porterr.IError {
+	if c.queue == nil {
+		return porterr.New(porterr.PortErrorArgument, "Queue options are not set")
+	}
+	return c.queueUpdate(q, c.queue.CompletedStatus, true, items)
+}
+
+// Worker process queue items concurrently
+// Processed items are completed, failed items are released
+type Worker[T any] struct {
+	// database connection
+	db godb.Queryer
+	// queue collection with conditions and order
+	queue *Collection[T]
+	// item handler
+	handler func(ctx context.Context, item *T) error
+	// BatchSize count of claimed items per poll
+	BatchSize int
+	// Concurrency count of concurrently processed items
+	Concurrency int
+	// Lease time to process claimed items
+	Lease time.Duration
+	// Interval delay between polls when queue is empty
+	Interval time.Duration
+}
+
+// Poll claim and process one batch of items
+// Returns count of claimed items and first claim, handler or update error
+func (w *Worker[T]) Poll(ctx context.Context) (count int, e porterr.IError) {
+	if e = w.queue.Claim(w.db, w.BatchSize, w.Lease); e != nil {
+		return
+	}
+	items := w.queue.Items()
+	count = len(items)
+	if count == 0 {
+		return
+	}
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	var semaphore = make(chan struct{}, concurrency)
+	var failed = make([]error, len(items))
+	for i := range items {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					failed[i] = porterr.New(porterr.PortErrorSystem, "Queue item handler panic")
+				}
+				<-semaphore
+				wg.Done()
+			}()
+			failed[i] = w.handler(ctx, items[i])
+		}(i)
+	}
+	wg.Wait()
+	var completed, released []*T
+	for i := range items {
+		if failed[i] == nil {
+			completed = append(completed, items[i])
+			continue
+		}
+		released = append(released, items[i])
+		if e == nil {
+			e = porterr.New(porterr.PortErrorConsumer, "Queue item handler error: "+failed[i].Error())
+		}
+	}
+	if len(completed) > 0 {
+		if ce := w.queue.Complete(w.db, completed...); ce != nil {
+			e = ce
+		}
+	}
+	if len(released) > 0 {
+		if re := w.queue.Release(w.db, released...); re != nil {
+			e = re
+		}
+	}
+	return
+}
+
+// Run poll items until context is done
+// onError - optional callback for poll errors
+func (w *Worker[T]) Run(ctx context.Context, onError func(e porterr.IError)) {
+	for {
+		count, e := w.Poll(ctx)
+		if e != nil && onError != nil {
+			onError(e)
+		}
+		if count < w.BatchSize {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.Interval):
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// NewWorker init queue worker
+// db - database connection
+// queue - collection with queue options, conditions and order
+// handler - process claimed item. Item is released on error
+func NewWorker[T any](db godb.Queryer, queue *Collection[T], handler func(ctx context.Context, item *T) error) *Worker[T] {
+	return &Worker[T]{
+		db:          db,
+		queue:       queue,
+		handler:     handler,
+		BatchSize:   WorkerDefaultBatchSize,
+		Concurrency: WorkerDefaultConcurrency,
+		Lease:       WorkerDefaultLease,
+		Interval:    WorkerDefaultInterval,
+	}
+}
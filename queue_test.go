@@ -0,0 +1,124 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+func TestCollection_getClaimSQL(t *testing.T) {
+	c := NewCollection[InsertModel1]()
+	if _, e := c.getClaimSQL(10, time.Minute); e == nil {
+		t.Fatal("queue options must be required")
+	}
+	c.WithQueue(QueueOptions{StatusColumn: "name", PendingStatus: "new", ClaimedStatus: "taken", LeaseColumn: "created_at"})
+	if _, e := c.getClaimSQL(0, time.Minute); e == nil {
+		t.Fatal("limit must be required")
+	}
+	c.Where().AddExpression("some_int > ?", 10)
+	c.AddOrder("id")
+	update, e := c.getClaimSQL(5, time.Second)
+	if e != nil {
+		t.Fatal(e)
+	}
+	query, params, _ := update.SQL()
+	expected := "UPDATE test_model_1 SET name = ?, created_at = NOW() + ? * INTERVAL '1 millisecond', updated_at = NOW() " +
+		"WHERE (id IN (SELECT test_model_1.id FROM test_model_1 WHERE ((some_int > ?) AND (test_model_1.name = ? OR (test_model_1.name = ? AND test_model_1.created_at < NOW())))" +
+		" ORDER BY id LIMIT 5 OFFSET 0 FOR UPDATE SKIP LOCKED)) RETURNING id, name, pages, some_int, created_at, updated_at, deleted_at;"
+	if query != expected {
+		t.Fatal("wrong claim query: " + query)
+	}
+	if len(params) != 5 || params[0] != "taken" || params[1] != int64(1000) || params[2] != 10 || params[3] != "new" || params[4] != "taken" {
+		t.Fatal("wrong claim params")
+	}
+	if c.String() != "SELECT id, name, pages, some_int, created_at, updated_at, deleted_at FROM test_model_1 WHERE (some_int > ?) ORDER BY id" {
+		t.Fatal("collection query must not be changed: " + c.String())
+	}
+	c.Select.AddOrder("name")
+	if _, e = c.getClaimSQL(5, time.Second); e == nil {
+		t.Fatal("not tracked order must be rejected")
+	}
+	lease := NewCollection[InsertModel1]().WithQueue(QueueOptions{LeaseColumn: "created_at"})
+	update, _ = lease.getClaimSQL(1, time.Second)
+	query, _, _ = update.SQL()
+	if query != "UPDATE test_model_1 SET created_at = NOW() + ? * INTERVAL '1 millisecond', updated_at = NOW() "+
+		"WHERE (id IN (SELECT test_model_1.id FROM test_model_1 WHERE ((test_model_1.created_at IS NULL OR test_model_1.created_at < NOW())) LIMIT 1 OFFSET 0 FOR UPDATE SKIP LOCKED)) "+
+		"RETURNING id, name, pages, some_int, created_at, updated_at, deleted_at;" {
+		t.Fatal("wrong lease claim query: " + query)
+	}
+}
+
+func TestCollection_getQueueUpdateSQL(t *testing.T) {
+	c := NewCollection[InsertModel1]().WithQueue(QueueOptions{StatusColumn: "name", PendingStatus: "new", ClaimedStatus: "taken", CompletedStatus: "done"})
+	isql, _, e := c.getQueueUpdateSQL("done", true, nil)
+	if e != nil || isql != nil {
+		t.Fatal("empty collection must be without query")
+	}
+	leased := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 1; i <= 2; i++ {
+		id := i
+		c.AddItem(&InsertModel1{Id: &id, CreatedAt: &leased})
+	}
+	isql, expected, _ := c.getQueueUpdateSQL("done", true, nil)
+	query, params, _ := isql.SQL()
+	if query != "UPDATE test_model_1 SET name = ?, updated_at = NOW() WHERE (id = ANY(?) AND name = ?);" || len(params) != 3 || params[2] != "taken" || expected != 2 {
+		t.Fatal("wrong complete query: " + query)
+	}
+	lease := NewCollection[InsertModel1]().WithQueue(QueueOptions{LeaseColumn: "created_at"})
+	isql, _, _ = lease.getQueueUpdateSQL(nil, true, c.Items()[:1])
+	query, params, _ = isql.SQL()
+	if query != "DELETE FROM test_model_1 WHERE (id = ANY(?) AND created_at = ANY(?));" || len(params) != 2 {
+		t.Fatal("wrong lease complete query: " + query)
+	}
+	if leases, ok := params[1].(pq.GenericArray); !ok || leases.A.([]any)[0] != leased {
+		t.Fatal("wrong lease param")
+	}
+	isql, _, _ = lease.getQueueUpdateSQL(nil, false, c.Items()[:1])
+	query, _, _ = isql.SQL()
+	if query != "UPDATE test_model_1 SET created_at = NULL, updated_at = NOW() WHERE (id = ANY(?) AND created_at = ANY(?));" {
+		t.Fatal("wrong lease release query: " + query)
+	}
+	id := 3
+	if _, _, e = lease.getQueueUpdateSQL(nil, false, []*InsertModel1{{Id: &id}}); e == nil {
+		t.Fatal("item without lease must be rejected")
+	}
+	if _, _, e = NewCollection[TenantModel]().WithQueue(QueueOptions{StatusColumn: "name"}).getQueueUpdateSQL(nil, false, nil); e == nil {
+		t.Fatal("not scoped tenant collection must be rejected")
+	}
+	if NewCollection[InsertModel1]().Release(nil) == nil || NewCollection[InsertModel1]().Complete(nil) == nil {
+		t.Fatal("queue options must be required")
+	}
+	t.Run("lost", func(t *testing.T) {
+		dbo, _ := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			return fakeResult{affected: 1}
+		})
+		if e := c.Complete(dbo); e == nil || e.GetCode() != porterr.PortErrorConflict {
+			t.Fatal("lost claim must be conflict")
+		}
+		if e := c.Release(dbo, c.First()); e != nil {
+			t.Fatal(e)
+		}
+	})
+}
+
+func TestWorker(t *testing.T) {
+	w := NewWorker[InsertModel1](nil, NewCollection[InsertModel1](), func(ctx context.Context, item *InsertModel1) error {
+		return errors.New("must not be called")
+	})
+	if _, e := w.Poll(context.Background()); e == nil {
+		t.Fatal("queue options must be required")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var errs int
+	w.Interval = time.Millisecond
+	w.Run(ctx, func(e porterr.IError) { errs++ })
+	if errs != 1 {
+		t.Fatal("run must stop on done context")
+	}
+}
@@ -0,0 +1,258 @@
+package gomodel
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+// isSameTable check if foreign key table reference model table
+// Table without schema is in DefaultSchema
+func isSameTable(reference, table string) bool {
+	if !strings.Contains(reference, ".") {
+		reference = DefaultSchema + "." + reference
+	}
+	if !strings.Contains(table, ".") {
+		table = DefaultSchema + "." + table
+	}
+	return reference == table
+}
+
+// relatedCondition add soft delete and tenant conditions for related model
+// Related rows of multi-tenant model must belong to the tenant of model
+func relatedCondition(cond *gosql.Condition, model IModel, related *MetaModel, relatedModel IModel) porterr.IError {
+	for i := range related.Fields {
+		if related.Fields[i].IsDeletedAt {
+			cond.AddExpression(related.Fields[i].Column + " IS NULL")
+		}
+	}
+	if tenant := related.Fields.Tenant(); tenant != nil {
+		pos := tenantPosition(model)
+		if pos < 0 || !isTenantSet(model) {
+			return errorNoTenant(relatedModel)
+		}
+		cond.AddExpression(tenant.Column+" = ?", model.Values()[pos])
+	}
+	return nil
+}
+
+// LoadRelated load model referenced by foreign key field (belongs-to)
+// field - pointer to model field with frk tag
+// target - referenced model
+func LoadRelated(q godb.Queryer, model IModel, field any, target IModel) porterr.IError {
+	query, e := getRelatedSQL(model, field, target)
+	if e != nil {
+		return e
+	}
+	if e = Do(q, query); e != nil {
+		return e
+	}
+	return decryptModel(target)
+}
+
+// getRelatedSQL query for model referenced by foreign key field
+func getRelatedSQL(model IModel, field any, target IModel) (*gosql.Select, porterr.IError) {
+	meta := PrepareMetaModel(model)
+	targetMeta := PrepareMetaModel(target)
+	if meta == nil || targetMeta == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Model and target must not be nil")
+	}
+	fk := meta.Fields.ByValue(field)
+	if fk == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Field is not found in model "+model.Table())
+	}
+	table, columns := fk.ForeignReference()
+	if len(columns) != 1 || !isSameTable(table, target.Table()) {
+		return nil, porterr.New(porterr.PortErrorArgument, "Field "+fk.Column+" has no foreign key to "+target.Table())
+	}
+	if fk.IsNil {
+		return nil, porterr.New(porterr.PortErrorSearch, "Foreign key "+fk.Column+" is empty").HTTP(http.StatusNotFound)
+	}
+	query := gosql.NewSelect().From(target.Table())
+	for i := range targetMeta.Fields {
+		if targetMeta.Fields[i].Column != "" {
+			query.Columns().Append(targetMeta.Fields[i].Column, targetMeta.Fields[i].Value)
+		}
+	}
+	query.Where().AddExpression(columns[0]+" = ?", fk.Value)
+	if e := relatedCondition(query.Where(), model, targetMeta, target); e != nil {
+		return nil, e
+	}
+	return query, nil
+}
+
+// LoadMany load models referencing model by foreign key (has-many)
+// First foreign key of T to model table is used
+func LoadMany[T any](q godb.Queryer, model IModel) (*Collection[T], porterr.IError) {
+	collection, e := newManyCollection[T](model)
+	if e != nil {
+		return nil, e
+	}
+	if e = collection.Load(q); e != nil {
+		return nil, e
+	}
+	return collection, nil
+}
+
+// newManyCollection collection of models referencing model by foreign key
+func newManyCollection[T any](model IModel) (*Collection[T], porterr.IError) {
+	collection := NewCollection[T]()
+	if collection == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Type T is not implements IModel interface")
+	}
+	meta := PrepareMetaModel(model)
+	if meta == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, "Model must not be nil")
+	}
+	var item interface{} = new(T)
+	related := item.(IModel)
+	relatedMeta := PrepareMetaModel(related)
+	var fk, key *ModelFiledTag
+	for i := range relatedMeta.Fields {
+		table, columns := relatedMeta.Fields[i].ForeignReference()
+		if len(columns) == 1 && isSameTable(table, model.Table()) {
+			fk = &relatedMeta.Fields[i]
+			key = meta.Fields.ByColumn(columns[0])
+			break
+		}
+	}
+	if fk == nil || key == nil {
+		return nil, porterr.New(porterr.PortErrorArgument, related.Table()+" has no foreign key to "+model.Table())
+	}
+	if key.IsNil {
+		return nil, porterr.New(porterr.PortErrorSearch, "Key "+key.Column+" is empty").HTTP(http.StatusNotFound)
+	}
+	collection.Where().AddExpression(fk.Column+" = ?", key.Value)
+	if e := relatedCondition(collection.Where(), model, relatedMeta, related); e != nil {
+		return nil, e
+	}
+	collection.scoped = true
+	return collection, nil
+}
+
+// relationKey comparable value of model field. Pointers are dereferenced
+func relationKey(value any) (key any, ok bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || !v.Type().Comparable() {
+		return
+	}
+	return v.Interface(), true
+}
+
+// Preload load related models for all collection items in one query
+// field - pointer to field of c.Model() with foreign key to R (belongs-to)
+// or to key field of c.Model() referenced by foreign key of R (has-many)
+// set - called for each pair of item and related model. For has-many called once per related model
+func Preload[T, R any](q godb.Queryer, c *Collection[T], field any, set func(item *T, related *R)) (*Collection[R], porterr.IError) {
+	related, itemKey, relatedKey, e := newPreloadCollection[T, R](c, field)
+	if e != nil {
+		return nil, e
+	}
+	if related == nil {
+		return NewCollection[R](), nil
+	}
+	if e = related.Load(q); e != nil {
+		return nil, e
+	}
+	attachRelated(c.Items(), related.Items(), itemKey, relatedKey, set)
+	return related, nil
+}
+
+// attachRelated call set for items and related models with equal keys
+func attachRelated[T, R any](items []*T, related []*R, itemKey, relatedKey int, set func(item *T, related *R)) {
+	var index = make(map[any][]*T, len(items))
+	for _, item := range items {
+		if key, ok := relationKey((interface{})(item).(IModel).Values()[itemKey]); ok {
+			index[key] = append(index[key], item)
+		}
+	}
+	for _, r := range related {
+		if key, ok := relationKey((interface{})(r).(IModel).Values()[relatedKey]); ok {
+			for _, item := range index[key] {
+				set(item, r)
+			}
+		}
+	}
+}
+
+// newPreloadCollection collection of related models for collection items
+// itemKey, relatedKey - positions of join fields in T and R values
+// Returns nil collection if collection items have no keys
+func newPreloadCollection[T, R any](c *Collection[T], field any) (related *Collection[R], itemKey int, relatedKey int, e porterr.IError) {
+	related = NewCollection[R]()
+	var item interface{} = new(T)
+	if related == nil || c == nil {
+		e = porterr.New(porterr.PortErrorArgument, "Type T and R must implement IModel interface")
+		return
+	}
+	if _, ok := item.(IModel); !ok {
+		e = porterr.New(porterr.PortErrorArgument, "Type T and R must implement IModel interface")
+		return
+	}
+	var relatedItem interface{} = new(R)
+	meta := PrepareMetaModel((interface{})(c.Model()).(IModel))
+	relatedMeta := PrepareMetaModel(relatedItem.(IModel))
+	fk := meta.Fields.ByValue(field)
+	if fk == nil {
+		e = porterr.New(porterr.PortErrorArgument, "Field is not found in model "+meta.TableName)
+		return
+	}
+	itemKey, relatedKey = columnPosition(meta.Fields, fk.Column), -1
+	var relatedColumn string
+	if table, columns := fk.ForeignReference(); len(columns) == 1 && isSameTable(table, relatedMeta.TableName) {
+		// belongs-to
+		relatedKey, relatedColumn = columnPosition(relatedMeta.Fields, columns[0]), columns[0]
+	} else {
+		// has-many
+		for i := range relatedMeta.Fields {
+			table, columns = relatedMeta.Fields[i].ForeignReference()
+			if len(columns) == 1 && columns[0] == fk.Column && isSameTable(table, meta.TableName) {
+				relatedKey, relatedColumn = i, relatedMeta.Fields[i].Column
+				break
+			}
+		}
+	}
+	if relatedKey < 0 {
+		e = porterr.New(porterr.PortErrorArgument, "Field "+fk.Column+" is not a foreign key between "+meta.TableName+" and "+relatedMeta.TableName)
+		return
+	}
+	var keys = make([]any, 0, c.Count())
+	var exists = make(map[any]struct{}, c.Count())
+	for _, i := range c.Items() {
+		if key, ok := relationKey((interface{})(i).(IModel).Values()[itemKey]); ok {
+			if _, ok = exists[key]; !ok {
+				exists[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		related = nil
+		return
+	}
+	related.Where().AddExpression(relatedColumn+" = ANY(?)", pq.Array(keys))
+	e = relatedCondition(related.Where(), (interface{})(c.First()).(IModel), relatedMeta, relatedItem.(IModel))
+	related.scoped = e == nil
+	return
+}
+
+// columnPosition position of column in fields. -1 if not found
+func columnPosition(fields ModelFiledTagList, column string) int {
+	for i := range fields {
+		if fields[i].Column == column {
+			return i
+		}
+	}
+	return -1
+}
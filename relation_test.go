@@ -0,0 +1,230 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type RelationAuthor struct {
+	Id        *int       `json:"id" db:"col~id;prk;req;seq;"`
+	Name      *string    `json:"name" db:"col~name;req;"`
+	DeletedAt *time.Time `json:"deletedAt" db:"col~deleted_at;dat;"`
+}
+
+// Model table name
+func (m *RelationAuthor) Table() string { return "author" }
+
+// Model columns
+func (m *RelationAuthor) Columns() []string {
+	return []string{"id", "name", "deleted_at"}
+}
+
+// Model values
+func (m *RelationAuthor) Values() []any {
+	return []any{&m.Id, &m.Name, &m.DeletedAt}
+}
+
+type RelationBook struct {
+	Id       *int    `json:"id" db:"col~id;prk;req;seq;"`
+	AuthorId *int    `json:"authorId" db:"col~author_id;frk~public.author.id;req;"`
+	Title    *string `json:"title" db:"col~title;req;"`
+}
+
+// Model table name
+func (m *RelationBook) Table() string { return "book" }
+
+// Model columns
+func (m *RelationBook) Columns() []string {
+	return []string{"id", "author_id", "title"}
+}
+
+// Model values
+func (m *RelationBook) Values() []any {
+	return []any{&m.Id, &m.AuthorId, &m.Title}
+}
+
+func TestForeignReference(t *testing.T) {
+	for tag, expected := range map[string][2]string{
+		"frk~public.author.id;":          {"public.author", "id"},
+		"frk~author.id;":                 {"author", "id"},
+		"frk~master.table(id,name);":     {"master.table", "id,name"},
+		"frk~author;":                    {"", ""},
+		"col~author_id;frk~a.b.c;unq;":   {"a.b", "c"},
+		"col~author_id;frk~a.b(c);unq;":  {"a.b", "c"},
+		"col~author_id;frk~a.b.c(d,e);":  {"a.b.c", "d,e"},
+		"col~author_id;frk~public.book;": {"public", "book"},
+	} {
+		var field ModelFiledTag
+		ParseModelFiledTag(tag, &field)
+		table, columns := field.ForeignReference()
+		var joined string
+		for i := range columns {
+			if i > 0 {
+				joined += ","
+			}
+			joined += columns[i]
+		}
+		if table != expected[0] || joined != expected[1] {
+			t.Fatal("wrong foreign reference for " + tag + ": " + table + " " + joined)
+		}
+	}
+}
+
+func TestLoadRelated(t *testing.T) {
+	authorId := 3
+	book := &RelationBook{AuthorId: &authorId}
+	author := &RelationAuthor{}
+	query, e := getRelatedSQL(book, &book.AuthorId, author)
+	if e != nil {
+		t.Fatal(e)
+	}
+	sql, params, returning := query.SQL()
+	t.Log(sql)
+	if sql != "SELECT id, name, deleted_at FROM author WHERE (id = ? AND deleted_at IS NULL)" {
+		t.Fatal("wrong related query")
+	}
+	if len(params) != 1 || params[0] != &book.AuthorId || len(returning) != 3 {
+		t.Fatal("wrong related params")
+	}
+	t.Run("errors", func(t *testing.T) {
+		if _, e := getRelatedSQL(book, &book.Title, author); e == nil {
+			t.Fatal("must be no foreign key error")
+		}
+		if _, e := getRelatedSQL(book, &book.AuthorId, &RelationBook{}); e == nil {
+			t.Fatal("must be wrong target error")
+		}
+		if _, e := getRelatedSQL(&RelationBook{}, &book.AuthorId, author); e == nil {
+			t.Fatal("must be field not found error")
+		}
+		empty := &RelationBook{}
+		if _, e := getRelatedSQL(empty, &empty.AuthorId, author); e == nil || e.GetHTTP() != 404 {
+			t.Fatal("must be empty key error")
+		}
+	})
+}
+
+func TestLoadMany(t *testing.T) {
+	authorId := 3
+	author := &RelationAuthor{Id: &authorId}
+	c, e := newManyCollection[RelationBook](author)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Log(c.String())
+	if c.String() != "SELECT id, author_id, title FROM book WHERE (author_id = ?)" {
+		t.Fatal("wrong has many query")
+	}
+	if _, e = newManyCollection[RelationAuthor](&RelationBook{}); e == nil {
+		t.Fatal("must be no foreign key error")
+	}
+	if _, e = newManyCollection[RelationBook](&RelationAuthor{}); e == nil {
+		t.Fatal("must be empty key error")
+	}
+}
+
+func TestPreload(t *testing.T) {
+	t.Run("belongs_to", func(t *testing.T) {
+		books := NewCollection[RelationBook]()
+		first, second := 1, 2
+		books.AddItem(&RelationBook{AuthorId: &first}, &RelationBook{AuthorId: &second}, &RelationBook{AuthorId: &first}, &RelationBook{})
+		authors, itemKey, relatedKey, e := newPreloadCollection[RelationBook, RelationAuthor](books, &books.Model().AuthorId)
+		if e != nil {
+			t.Fatal(e)
+		}
+		t.Log(authors.String())
+		if authors.String() != "SELECT id, name, deleted_at FROM author WHERE (id = ANY(?) AND deleted_at IS NULL)" {
+			t.Fatal("wrong belongs to query")
+		}
+		if itemKey != 1 || relatedKey != 0 {
+			t.Fatal("wrong keys")
+		}
+		value, _ := pqArrayValue(authors.GetArguments()[0])
+		if value != "{1,2}" {
+			t.Fatal("wrong keys argument: " + value)
+		}
+	})
+	t.Run("has_many", func(t *testing.T) {
+		authors := NewCollection[RelationAuthor]()
+		first, second := 1, 2
+		authors.AddItem(&RelationAuthor{Id: &first}, &RelationAuthor{Id: &second})
+		books, itemKey, relatedKey, e := newPreloadCollection[RelationAuthor, RelationBook](authors, &authors.Model().Id)
+		if e != nil {
+			t.Fatal(e)
+		}
+		t.Log(books.String())
+		if books.String() != "SELECT id, author_id, title FROM book WHERE (author_id = ANY(?))" {
+			t.Fatal("wrong has many query")
+		}
+		if itemKey != 0 || relatedKey != 1 {
+			t.Fatal("wrong keys")
+		}
+	})
+	t.Run("attach", func(t *testing.T) {
+		first, second, third := 1, 2, 3
+		authors := []*RelationAuthor{{Id: &first}, {Id: &second}, {Id: &third}}
+		books := []*RelationBook{{AuthorId: &first}, {AuthorId: &second}, {AuthorId: &first}, {}}
+		var count = make(map[int]int)
+		attachRelated(authors, books, 0, 1, func(item *RelationAuthor, related *RelationBook) {
+			if *item.Id != *related.AuthorId {
+				t.Fatal("wrong related model")
+			}
+			count[*item.Id]++
+		})
+		if count[1] != 2 || count[2] != 1 || count[3] != 0 {
+			t.Fatal("wrong attached models")
+		}
+	})
+	t.Run("load", func(t *testing.T) {
+		dbo, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			return fakeResult{columns: []string{"id", "name", "deleted_at"}, rows: [][]driver.Value{{int64(1), "first", nil}, {int64(2), "second", nil}}}
+		})
+		books := NewCollection[RelationBook]()
+		first, second := 1, 2
+		books.AddItem(&RelationBook{AuthorId: &first}, &RelationBook{AuthorId: &second}, &RelationBook{AuthorId: &first})
+		names := make(map[*RelationBook]string)
+		authors, e := Preload[RelationBook, RelationAuthor](dbo, books, &books.Model().AuthorId, func(book *RelationBook, author *RelationAuthor) {
+			names[book] = *author.Name
+		})
+		if e != nil || authors.Count() != 2 {
+			t.Fatal("authors must be loaded")
+		}
+		if len(names) != 3 || names[books.First()] != "first" || names[books.Items()[1]] != "second" {
+			t.Fatal("authors must be set to books")
+		}
+		if log := fake.log(); len(log) != 1 || log[0] != "SELECT id, name, deleted_at FROM author WHERE (id = ANY(?) AND deleted_at IS NULL)" {
+			t.Fatal("wrong preload queries")
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		books := NewCollection[RelationBook]()
+		c, e := Preload[RelationBook, RelationAuthor](nil, books, &books.Model().AuthorId, nil)
+		if e != nil || c == nil || c.Count() != 0 {
+			t.Fatal("empty collection must be without query")
+		}
+	})
+	t.Run("wrong_column", func(t *testing.T) {
+		books := NewCollection[RelationBook]()
+		_, e := Preload[RelationBook, RelationAuthor](nil, books, &books.Model().Title, nil)
+		if e == nil {
+			t.Fatal("must be an error")
+		}
+		_, e = Preload[RelationBook, RelationAuthor](nil, books, &(&RelationBook{}).AuthorId, nil)
+		if e == nil {
+			t.Fatal("field of not template model must be an error")
+		}
+		authors := NewCollection[RelationAuthor]()
+		_, e = Preload[RelationAuthor, RelationBook](nil, authors, &authors.Model().Name, nil)
+		if e == nil {
+			t.Fatal("not referenced field must be an error")
+		}
+	})
+}
+
+func pqArrayValue(value any) (string, error) {
+	v, err := value.(driver.Valuer).Value()
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
@@ -0,0 +1,344 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+)
+
+// RouterStrategy replica choosing strategy
+type RouterStrategy uint8
+
+const (
+	// RouterStrategyRoundRobin replicas are used one by one
+	RouterStrategyRoundRobin RouterStrategy = iota
+	// RouterStrategyLeastLatency replica with the smallest average latency is used
+	RouterStrategyLeastLatency
+)
+
+// RouterDefaultStickyWindow default period when reads go to primary after write
+const RouterDefaultStickyWindow = time.Second * 2
+
+// key for router session in context
+type routerSessionKey struct{}
+
+// router session. Keeps time of last write
+type routerSession struct {
+	// unix nano of last write
+	lastWrite int64
+}
+
+// mark write
+func (s *routerSession) write() {
+	if s != nil {
+		atomic.StoreInt64(&s.lastWrite, time.Now().UnixNano())
+	}
+}
+
+// check if write was in window
+func (s *routerSession) isSticky(window time.Duration) bool {
+	if s == nil {
+		return false
+	}
+	last := atomic.LoadInt64(&s.lastWrite)
+	return last > 0 && time.Since(time.Unix(0, last)) < window
+}
+
+// NewRouterContext attach read-your-writes session to context
+// All queryers received from Router.WithContext with such context share the session
+func NewRouterContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(routerSessionKey{}).(*routerSession); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routerSessionKey{}, &routerSession{})
+}
+
+// Router godb.Queryer implementation with read/write routing between primary and replicas
+// Select queries go to replica, all other queries go to primary
+type Router struct {
+	// primary connection
+	primary godb.Queryer
+	// replica connections
+	replicas []godb.Queryer
+	// average replica latency in nanoseconds
+	latency []int64
+	// round-robin counter
+	counter uint64
+	// Strategy of replica choosing
+	Strategy RouterStrategy
+	// StickyWindow period when reads go to primary after write in the same context
+	StickyWindow time.Duration
+	// mutex for latency
+	m sync.Mutex
+}
+
+// Primary get primary connection
+func (r *Router) Primary() godb.Queryer {
+	return r.primary
+}
+
+// Replica get replica connection according to strategy
+// Returns primary when no replicas
+func (r *Router) Replica() godb.Queryer {
+	return r.connection(r.replicaIndex())
+}
+
+// replicaIndex choose replica index. Returns -1 when no replicas
+func (r *Router) replicaIndex() int {
+	if len(r.replicas) == 0 {
+		return -1
+	}
+	if r.Strategy == RouterStrategyLeastLatency {
+		r.m.Lock()
+		defer r.m.Unlock()
+		var index int
+		for i := range r.latency {
+			if r.latency[i] < r.latency[index] {
+				index = i
+			}
+		}
+		return index
+	}
+	return int((atomic.AddUint64(&r.counter, 1) - 1) % uint64(len(r.replicas)))
+}
+
+// observe replica latency
+func (r *Router) observe(index int, start time.Time) {
+	if index < 0 || r.Strategy != RouterStrategyLeastLatency {
+		return
+	}
+	duration := int64(time.Since(start))
+	r.m.Lock()
+	if r.latency[index] == 0 {
+		r.latency[index] = duration
+	} else {
+		// exponentially weighted moving average
+		r.latency[index] = (r.latency[index]*7 + duration) / 8
+	}
+	r.m.Unlock()
+}
+
+// route query. Returns replica index or -1 for primary
+func (r *Router) route(query string, session *routerSession) int {
+	if !IsReadQuery(query) {
+		session.write()
+		return -1
+	}
+	if session.isSticky(r.StickyWindow) {
+		return -1
+	}
+	return r.replicaIndex()
+}
+
+// connection by index
+func (r *Router) connection(index int) godb.Queryer {
+	if index < 0 {
+		return r.primary
+	}
+	return r.replicas[index]
+}
+
+// exec on routed connection
+func (r *Router) exec(session *routerSession, query string, args ...any) (sql.Result, error) {
+	index := r.route(query, session)
+	defer r.observe(index, time.Now())
+	return r.connection(index).Exec(query, args...)
+}
+
+// prepare on routed connection
+func (r *Router) prepare(session *routerSession, query string) (*godb.SqlStmt, error) {
+	index := r.route(query, session)
+	defer r.observe(index, time.Now())
+	return r.connection(index).Prepare(query)
+}
+
+// query on routed connection
+func (r *Router) query(session *routerSession, query string, args ...any) (*sql.Rows, error) {
+	index := r.route(query, session)
+	defer r.observe(index, time.Now())
+	return r.connection(index).Query(query, args...)
+}
+
+// query row on routed connection
+func (r *Router) queryRow(session *routerSession, query string, args ...any) *sql.Row {
+	index := r.route(query, session)
+	defer r.observe(index, time.Now())
+	return r.connection(index).QueryRow(query, args...)
+}
+
+// Exec implementation of godb.Queryer
+func (r *Router) Exec(query string, args ...any) (sql.Result, error) {
+	return r.exec(nil, query, args...)
+}
+
+// Prepare implementation of godb.Queryer
+func (r *Router) Prepare(query string) (*godb.SqlStmt, error) {
+	return r.prepare(nil, query)
+}
+
+// Query implementation of godb.Queryer
+func (r *Router) Query(query string, args ...any) (*sql.Rows, error) {
+	return r.query(nil, query, args...)
+}
+
+// QueryRow implementation of godb.Queryer
+func (r *Router) QueryRow(query string, args ...any) *sql.Row {
+	return r.queryRow(nil, query, args...)
+}
+
+// Begin start transaction on primary. Primary must be *godb.DBO
+// All queries inside transaction go to primary
+func (r *Router) Begin() (*godb.SqlTx, error) {
+	if dbo, ok := r.primary.(*godb.DBO); ok {
+		return dbo.Begin()
+	}
+	return nil, errors.New("router primary connection does not support transactions")
+}
+
+// WithContext get queryer with read-your-writes session from context
+// After write all reads in sticky window go to primary
+// Use NewRouterContext to share session between several queryers
+func (r *Router) WithContext(ctx context.Context) godb.Queryer {
+	session, ok := ctx.Value(routerSessionKey{}).(*routerSession)
+	if !ok {
+		session = &routerSession{}
+	}
+	return &routerQueryer{router: r, session: session}
+}
+
+// router queryer with session
+type routerQueryer struct {
+	// router
+	router *Router
+	// session
+	session *routerSession
+}
+
+// Exec implementation of godb.Queryer
+func (q *routerQueryer) Exec(query string, args ...any) (sql.Result, error) {
+	return q.router.exec(q.session, query, args...)
+}
+
+// Prepare implementation of godb.Queryer
+func (q *routerQueryer) Prepare(query string) (*godb.SqlStmt, error) {
+	return q.router.prepare(q.session, query)
+}
+
+// Query implementation of godb.Queryer
+func (q *routerQueryer) Query(query string, args ...any) (*sql.Rows, error) {
+	return q.router.query(q.session, query, args...)
+}
+
+// QueryRow implementation of godb.Queryer
+func (q *routerQueryer) QueryRow(query string, args ...any) *sql.Row {
+	return q.router.queryRow(q.session, query, args...)
+}
+
+// readFunctions functions allowed in queries routed to replica
+var readFunctions = struct {
+	names map[string]struct{}
+	m     sync.RWMutex
+}{names: map[string]struct{}{}}
+
+func init() {
+	RegisterReadFunction(
+		// keywords followed by parenthesis
+		"select", "from", "where", "and", "or", "not", "in", "exists", "any", "all", "some", "on", "using", "as",
+		"over", "filter", "within", "values", "array", "row", "join", "lateral", "when", "then", "else", "by", "distinct",
+		// aggregate and window functions
+		"count", "sum", "avg", "min", "max", "array_agg", "string_agg", "json_agg", "jsonb_agg", "bool_and", "bool_or",
+		"row_number", "rank", "dense_rank", "lag", "lead", "first_value", "last_value",
+		// scalar functions without side effects
+		"coalesce", "nullif", "greatest", "least", "lower", "upper", "length", "trim", "substring", "concat",
+		"abs", "round", "ceil", "floor", "cast", "extract", "date_trunc", "array_length", "unnest",
+		"row_to_json", "to_json", "to_jsonb", "json_build_object", "jsonb_build_object",
+	)
+}
+
+// RegisterReadFunction allow queries calling functions to be routed to replica
+// Functions must not change data or depend on primary state. Names are case-insensitive
+func RegisterReadFunction(name ...string) {
+	readFunctions.m.Lock()
+	defer readFunctions.m.Unlock()
+	for i := range name {
+		readFunctions.names[strings.ToLower(name[i])] = struct{}{}
+	}
+}
+
+// isReadFunction check if function is registered for replica
+func isReadFunction(name string) bool {
+	readFunctions.m.RLock()
+	defer readFunctions.m.RUnlock()
+	_, ok := readFunctions.names[strings.ToLower(name)]
+	return ok
+}
+
+// callsWriteFunction check if query calls function not registered by RegisterReadFunction
+// String literals and quoted identifiers are skipped
+func callsWriteFunction(query string) bool {
+	var start = -1
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(query[i+1:], ch)
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+			start = -1
+		case ch == '_' || ch == '.' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9':
+			if start < 0 {
+				start = i
+			}
+		case ch == ' ' && start >= 0 && i+1 < len(query) && query[i+1] == '(':
+			// identifier and parenthesis separated by space
+		default:
+			if ch == '(' && start >= 0 {
+				name := strings.TrimSpace(query[start:i])
+				if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+					name = name[dot+1:]
+				}
+				if !isReadFunction(name) {
+					return true
+				}
+			}
+			start = -1
+		}
+	}
+	return false
+}
+
+// IsReadQuery check if query is a select without row locking and function calls with side effects
+// Select calling function not registered by RegisterReadFunction goes to primary. Example: SELECT nextval('seq')
+func IsReadQuery(query string) bool {
+	query = strings.TrimSpace(query)
+	if len(query) < 6 || !strings.EqualFold(query[:6], "SELECT") {
+		return false
+	}
+	upper := strings.ToUpper(query)
+	for _, lock := range []string{" FOR UPDATE", " FOR NO KEY UPDATE", " FOR SHARE", " FOR KEY SHARE"} {
+		if strings.Contains(upper, lock) {
+			return false
+		}
+	}
+	return !callsWriteFunction(query)
+}
+
+// NewRouter init router
+// primary - connection for writes and transactions
+// replicas - connections for reads
+func NewRouter(primary godb.Queryer, replicas ...godb.Queryer) *Router {
+	return &Router{
+		primary:      primary,
+		replicas:     replicas,
+		latency:      make([]int64, len(replicas)),
+		StickyWindow: RouterDefaultStickyWindow,
+	}
+}
@@ -0,0 +1,105 @@
+package gomodel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+)
+
+func TestIsReadQuery(t *testing.T) {
+	if !IsReadQuery(" SELECT id FROM test_model_1 WHERE (id = ?)") {
+		t.Fatal("select must be read query")
+	}
+	if IsReadQuery("SELECT id FROM test_model_1 WHERE (id = ?) FOR UPDATE") {
+		t.Fatal("select for update must not be read query")
+	}
+	if IsReadQuery("UPDATE test_model_1 SET name = ? WHERE (id = ?)") {
+		t.Fatal("update must not be read query")
+	}
+	if IsReadQuery("INSERT INTO test_model_1 (name) VALUES (?) RETURNING id;") {
+		t.Fatal("insert must not be read query")
+	}
+	for _, query := range []string{
+		"SELECT nextval('test_model_1_id_seq')",
+		"SELECT pg_advisory_lock(?)",
+		"SELECT pg_catalog.pg_try_advisory_lock (?)",
+		"SELECT id, refresh_stats(id) FROM test_model_1",
+	} {
+		if IsReadQuery(query) {
+			t.Fatal("select with function call must not be read query: " + query)
+		}
+	}
+	for _, query := range []string{
+		"SELECT COUNT(*) OVER() AS total, id FROM test_model_1 WHERE (id IN (?, ?) AND name = 'nextval(x)')",
+		"SELECT \"count\" FROM test_model_1 WHERE EXISTS (SELECT 1 FROM test_model_2)",
+	} {
+		if !IsReadQuery(query) {
+			t.Fatal("select with read functions must be read query: " + query)
+		}
+	}
+	RegisterReadFunction("Calc_Rank")
+	if !IsReadQuery("SELECT calc_rank(id) FROM test_model_1") {
+		t.Fatal("registered function must be read query")
+	}
+}
+
+func TestRouter(t *testing.T) {
+	primary, replica1, replica2 := &godb.DBO{}, &godb.DBO{}, &godb.DBO{}
+	load, _, _ := GetLoadSQL(&InsertModel1{Id: &ACMId}).SQL()
+	save, _, _ := GetSaveSQL(&InsertModel1{Id: &ACMId}).SQL()
+	t.Run("round_robin", func(t *testing.T) {
+		r := NewRouter(primary, replica1, replica2)
+		if r.connection(r.route(load, nil)) != replica1 {
+			t.Fatal("must be replica 1")
+		}
+		if r.connection(r.route(load, nil)) != replica2 {
+			t.Fatal("must be replica 2")
+		}
+		if r.connection(r.route(load, nil)) != replica1 {
+			t.Fatal("must be replica 1 again")
+		}
+		if r.connection(r.route(save, nil)) != primary {
+			t.Fatal("save must be on primary")
+		}
+	})
+	t.Run("least_latency", func(t *testing.T) {
+		r := NewRouter(primary, replica1, replica2)
+		r.Strategy = RouterStrategyLeastLatency
+		r.observe(0, time.Now().Add(-time.Millisecond*10))
+		r.observe(1, time.Now().Add(-time.Millisecond))
+		if r.Replica() != replica2 {
+			t.Fatal("must be replica 2")
+		}
+	})
+	t.Run("no_replicas", func(t *testing.T) {
+		r := NewRouter(primary)
+		if r.connection(r.route(load, nil)) != primary {
+			t.Fatal("must be primary")
+		}
+	})
+	t.Run("sticky", func(t *testing.T) {
+		r := NewRouter(primary, replica1)
+		r.StickyWindow = time.Millisecond * 50
+		ctx := NewRouterContext(context.Background())
+		session := r.WithContext(ctx).(*routerQueryer).session
+		if session != r.WithContext(ctx).(*routerQueryer).session {
+			t.Fatal("session must be shared")
+		}
+		if r.connection(r.route(load, session)) != replica1 {
+			t.Fatal("must be replica before write")
+		}
+		r.route(save, session)
+		if r.connection(r.route(load, session)) != primary {
+			t.Fatal("must be primary after write")
+		}
+		if r.connection(r.route(load, nil)) != replica1 {
+			t.Fatal("other context must be replica")
+		}
+		time.Sleep(time.Millisecond * 60)
+		if r.connection(r.route(load, session)) != replica1 {
+			t.Fatal("must be replica after sticky window")
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+// SaveOperation operation performed by save
+type SaveOperation string
+
+const (
+	// SaveOperationInsert row inserted
+	SaveOperationInsert SaveOperation = "insert"
+	// SaveOperationUpdate row updated
+	SaveOperationUpdate SaveOperation = "update"
+	// SaveOperationUpsertInsert upsert inserted new row
+	SaveOperationUpsertInsert SaveOperation = "upsert_insert"
+	// SaveOperationUpsertUpdate upsert updated existing row
+	SaveOperationUpsertUpdate SaveOperation = "upsert_update"
+	// SaveOperationNoop nothing was changed. Row not found or conflict condition is false
+	SaveOperationNoop SaveOperation = "noop"
+)
+
+// SaveResult result of save
+type SaveResult struct {
+	// Operation performed operation
+	Operation SaveOperation
+	// RowsAffected count of affected rows
+	RowsAffected int64
+}
+
+// Inserted check if row was created
+func (r SaveResult) Inserted() bool {
+	return r.Operation == SaveOperationInsert || r.Operation == SaveOperationUpsertInsert
+}
+
+// insertedReturning add (xmax = 0) AS inserted to upsert returning list
+// xmax of inserted row is 0, updated row has xmax of updating transaction
+func insertedReturning(query string) string {
+	query = strings.TrimSuffix(query, ";")
+	if strings.Contains(query, " RETURNING ") {
+		return query + ", (xmax = 0) AS inserted;"
+	}
+	return query + " RETURNING (xmax = 0) AS inserted;"
+}
+
+// saveWithResult exec save query and detect performed operation
+func saveWithResult(q godb.Queryer, model IModel) (result SaveResult, e porterr.IError) {
+	isql, e := getSaveSQL(IndexCache, model)
+	if e != nil {
+		return result, e
+	}
+	query, params, returning := isql.SQL()
+	params = bindClock(q, params)
+	insert, update, upsert := getSaveScenario(model)
+	var err error
+	switch {
+	case update:
+		result.Operation = SaveOperationUpdate
+		if len(returning) > 0 {
+			err = q.QueryRow(query, params...).Scan(returning...)
+			result.RowsAffected = 1
+		} else {
+			var r sql.Result
+			if r, err = q.Exec(query, params...); err == nil {
+				result.RowsAffected, err = r.RowsAffected()
+			}
+		}
+	case insert:
+		result.Operation = SaveOperationInsert
+		if len(returning) > 0 {
+			err = q.QueryRow(query, params...).Scan(returning...)
+		} else {
+			_, err = q.Exec(query, params...)
+		}
+		result.RowsAffected = 1
+	case upsert:
+		var inserted bool
+		err = q.QueryRow(insertedReturning(query), params...).Scan(append(returning, &inserted)...)
+		result.Operation, result.RowsAffected = SaveOperationUpsertUpdate, 1
+		if inserted {
+			result.Operation = SaveOperationUpsertInsert
+		}
+	}
+	if err == sql.ErrNoRows {
+		return SaveResult{Operation: SaveOperationNoop}, nil
+	}
+	if err != nil {
+		return SaveResult{}, NewDatabaseError(err)
+	}
+	if result.RowsAffected == 0 {
+		result.Operation = SaveOperationNoop
+	}
+	return
+}
+
+// SaveWithResult save model and report performed operation
+func SaveWithResult(q godb.Queryer, model IModel) (SaveResult, porterr.IError) {
+	return SaveWithResultContext(context.Background(), q, model)
+}
+
+// SaveWithResultContext save model and report performed operation
+// ctx - context with actor for audit and tenant for multi-tenant models
+// Outbox events are not inserted for no-op save
+func SaveWithResultContext(ctx context.Context, q godb.Queryer, model IModel) (result SaveResult, e porterr.IError) {
+	start := time.Now()
+	if e = applyTenant(ctx, model); e != nil {
+		Metrics.Observe(model.Table(), IndexOperationSave, start, e)
+		return
+	}
+	e = Audit.track(ctx, q, model, IndexOperationSave, func() porterr.IError {
+		var e porterr.IError
+		if result, e = saveWithResult(q, model); e != nil || result.Operation == SaveOperationNoop {
+			return e
+		}
+		return Outbox.emit(q, model, IndexOperationSave)
+	})
+	Metrics.Observe(model.Table(), IndexOperationSave, start, e)
+	return
+}
@@ -0,0 +1,140 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertedReturning(t *testing.T) {
+	id, name := 1, "name"
+	query, _, _ := GetSaveSQL(&UpsertModel2{Id: &id, Name: &name}).SQL()
+	query = insertedReturning(query)
+	if query != "INSERT INTO test_model_up_2 (id, name, pages, some_int) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = ?, pages = ?, some_int = ?, updated_at = NOW() RETURNING created_at, updated_at, deleted_at, (xmax = 0) AS inserted;" {
+		t.Fatal("wrong upsert query: " + query)
+	}
+	query = insertedReturning("INSERT INTO t (id) VALUES (?) ON CONFLICT (id) DO UPDATE SET id = ?;")
+	if query != "INSERT INTO t (id) VALUES (?) ON CONFLICT (id) DO UPDATE SET id = ? RETURNING (xmax = 0) AS inserted;" {
+		t.Fatal("wrong upsert query without returning: " + query)
+	}
+}
+
+func TestSaveResult_Inserted(t *testing.T) {
+	for operation, inserted := range map[SaveOperation]bool{
+		SaveOperationInsert:       true,
+		SaveOperationUpsertInsert: true,
+		SaveOperationUpdate:       false,
+		SaveOperationUpsertUpdate: false,
+		SaveOperationNoop:         false,
+	} {
+		if (SaveResult{Operation: operation}).Inserted() != inserted {
+			t.Fatal("wrong inserted for " + string(operation))
+		}
+	}
+	if _, e := SaveWithResult(nil, &TenantModel{}); e == nil {
+		t.Fatal("tenant model without tenant must be error")
+	}
+}
+
+func TestSaveWithResult(t *testing.T) {
+	now := time.Now().UTC()
+	dates := []driver.Value{now, now, nil}
+	id, name := 1, "name"
+	for _, tc := range []struct {
+		name      string
+		model     IModel
+		result    fakeResult
+		query     string
+		operation SaveOperation
+		affected  int64
+	}{
+		{
+			name:      "insert",
+			model:     &InsertModel1{Name: &name},
+			result:    fakeResult{columns: []string{"id", "created_at", "updated_at", "deleted_at"}, rows: [][]driver.Value{append([]driver.Value{int64(10)}, dates...)}},
+			query:     "INSERT INTO test_model_1 (name, pages, some_int) VALUES (?, ?, ?) RETURNING id, created_at, updated_at, deleted_at;",
+			operation: SaveOperationInsert,
+			affected:  1,
+		},
+		{
+			name:      "update",
+			model:     &UpdateModel1{Id: &id, Name: &name},
+			result:    fakeResult{columns: []string{"created_at", "updated_at", "deleted_at"}, rows: [][]driver.Value{dates}},
+			query:     "UPDATE test_model_upd_1 SET name = ?, pages = ?, some_int = ?, updated_at = NOW() WHERE (id = ?) RETURNING created_at, updated_at, deleted_at;",
+			operation: SaveOperationUpdate,
+			affected:  1,
+		},
+		{
+			name:      "update_exec",
+			model:     &RelationBook{Id: &id, AuthorId: &id, Title: &name},
+			result:    fakeResult{affected: 1},
+			query:     "UPDATE book SET author_id = ?, title = ? WHERE (id = ?);",
+			operation: SaveOperationUpdate,
+			affected:  1,
+		},
+		{
+			name:      "upsert_inserted",
+			model:     &UpsertModel2{Id: &id, Name: &name},
+			result:    fakeResult{columns: []string{"created_at", "updated_at", "deleted_at", "inserted"}, rows: [][]driver.Value{append(dates, true)}},
+			query:     "INSERT INTO test_model_up_2 (id, name, pages, some_int) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = ?, pages = ?, some_int = ?, updated_at = NOW() RETURNING created_at, updated_at, deleted_at, (xmax = 0) AS inserted;",
+			operation: SaveOperationUpsertInsert,
+			affected:  1,
+		},
+		{
+			name:      "upsert_updated",
+			model:     &UpsertModel2{Id: &id, Name: &name},
+			result:    fakeResult{columns: []string{"created_at", "updated_at", "deleted_at", "inserted"}, rows: [][]driver.Value{append(dates, false)}},
+			query:     "INSERT INTO test_model_up_2 (id, name, pages, some_int) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = ?, pages = ?, some_int = ?, updated_at = NOW() RETURNING created_at, updated_at, deleted_at, (xmax = 0) AS inserted;",
+			operation: SaveOperationUpsertUpdate,
+			affected:  1,
+		},
+		{
+			name:      "noop",
+			model:     &RelationBook{Id: &id, AuthorId: &id, Title: &name},
+			result:    fakeResult{affected: 0},
+			query:     "UPDATE book SET author_id = ?, title = ? WHERE (id = ?);",
+			operation: SaveOperationNoop,
+		},
+		{
+			name:      "not_found",
+			model:     &UpdateModel1{Id: &id, Name: &name},
+			result:    fakeResult{columns: []string{"created_at", "updated_at", "deleted_at"}},
+			query:     "UPDATE test_model_upd_1 SET name = ?, pages = ?, some_int = ?, updated_at = NOW() WHERE (id = ?) RETURNING created_at, updated_at, deleted_at;",
+			operation: SaveOperationNoop,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dbo, fake := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+				return tc.result
+			})
+			result, e := SaveWithResult(dbo, tc.model)
+			if e != nil {
+				t.Fatal(e)
+			}
+			if result.Operation != tc.operation || result.RowsAffected != tc.affected {
+				t.Fatalf("wrong result: %+v", result)
+			}
+			if log := fake.log(); len(log) != 1 || log[0] != tc.query {
+				t.Fatal("wrong queries: " + strings.Join(log, "\n"))
+			}
+		})
+	}
+	t.Run("inserted_id", func(t *testing.T) {
+		dbo, _ := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			return fakeResult{columns: []string{"id", "created_at", "updated_at", "deleted_at"}, rows: [][]driver.Value{append([]driver.Value{int64(10)}, dates...)}}
+		})
+		model := &InsertModel1{Name: &name}
+		if _, e := SaveWithResult(dbo, model); e != nil || model.Id == nil || *model.Id != 10 || model.CreatedAt == nil {
+			t.Fatal("returning must be scanned to model")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		dbo, _ := newFakeDBO(func(query string, args []driver.Value) fakeResult {
+			return fakeResult{err: driver.ErrBadConn}
+		})
+		if result, e := SaveWithResult(dbo, &UpdateModel1{Id: &id, Name: &name}); e == nil || result.Operation != "" {
+			t.Fatal("query error must be returned")
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package gomodel
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/gohelp"
+	"github.com/dimonrus/porterr"
+)
+
+// UnknownColumns handling of result columns without model field
+type UnknownColumns uint8
+
+const (
+	// UnknownColumnsIgnore skip unknown columns
+	UnknownColumnsIgnore UnknownColumns = iota
+	// UnknownColumnsError return error on unknown column
+	UnknownColumnsError
+	// UnknownColumnsCapture pass unknown columns to ColumnCapturer model
+	UnknownColumnsCapture
+)
+
+// ScanOptions options of rows scanning
+type ScanOptions struct {
+	// Unknown columns handling. Ignore by default
+	Unknown UnknownColumns
+}
+
+// ColumnCapturer model receives values of unknown columns in UnknownColumnsCapture mode
+type ColumnCapturer interface {
+	// CaptureColumn value of unknown column
+	CaptureColumn(column string, value any)
+}
+
+// columnTarget path to column destination in struct
+type columnTarget struct {
+	// indexes of nested model fields. Nil pointers are allocated
+	path []int
+	// position in model values. -1 if field is not a model column
+	value int
+	// index of struct field. -1 if column is unknown
+	field int
+}
+
+// rowMapper destinations of result columns for struct type
+type rowMapper struct {
+	// result column names
+	columns []string
+	// destination of each column
+	targets []columnTarget
+	// distinct paths to nested models. Deeper paths are first
+	nested [][]int
+	// pass unknown columns to ColumnCapturer
+	capture bool
+}
+
+// modelType IModel interface type
+var modelType = reflect.TypeOf((*IModel)(nil)).Elem()
+
+// columns of model types
+var typeColumns sync.Map
+
+// isModelStruct check if *t implements IModel
+func isModelStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(modelType)
+}
+
+// modelColumns columns of model type
+func modelColumns(t reflect.Type) []string {
+	if columns, ok := typeColumns.Load(t); ok {
+		return columns.([]string)
+	}
+	columns := reflect.New(t).Interface().(IModel).Columns()
+	typeColumns.Store(t, columns)
+	return columns
+}
+
+// fieldPrefix prefix of nested model columns. Column tag or field name in snake case
+func fieldPrefix(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("db"); ok {
+		var field ModelFiledTag
+		ParseModelFiledTag(tag, &field)
+		if field.Column != "" {
+			return field.Column
+		}
+	}
+	return gohelp.ToUnderscore(f.Name)
+}
+
+// nestedModel type of model in struct field. Nil if field is not a model
+func nestedModel(f reflect.StructField) reflect.Type {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !f.IsExported() || !isModelStruct(t) {
+		return nil
+	}
+	return t
+}
+
+// resolveColumn find destination of column in struct type
+// Column is searched in model columns, tagged fields and embedded models
+// Prefixed column author__name is searched in nested model with prefix author
+func resolveColumn(t reflect.Type, column string, path []int) (columnTarget, bool) {
+	if isModelStruct(t) {
+		columns := modelColumns(t)
+		for i := range columns {
+			if columns[i] == column {
+				return columnTarget{path: path, value: i, field: -1}, true
+			}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if tag, ok := t.Field(i).Tag.Lookup("db"); ok && t.Field(i).IsExported() {
+			var field ModelFiledTag
+			ParseModelFiledTag(tag, &field)
+			if field.Column == column && nestedModel(t.Field(i)) == nil {
+				return columnTarget{path: path, value: -1, field: i}, true
+			}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if nt := nestedModel(t.Field(i)); nt != nil && t.Field(i).Anonymous {
+			if target, ok := resolveColumn(nt, column, append(path[:len(path):len(path)], i)); ok {
+				return target, true
+			}
+		}
+	}
+	if prefix, rest, ok := strings.Cut(column, "__"); ok {
+		for i := 0; i < t.NumField(); i++ {
+			if nt := nestedModel(t.Field(i)); nt != nil && fieldPrefix(t.Field(i)) == prefix {
+				return resolveColumn(nt, rest, append(path[:len(path):len(path)], i))
+			}
+		}
+	}
+	return columnTarget{}, false
+}
+
+// newRowMapper map result columns to struct type
+func newRowMapper(t reflect.Type, columns []string, options ScanOptions) (*rowMapper, porterr.IError) {
+	if t.Kind() != reflect.Struct {
+		return nil, porterr.New(porterr.PortErrorArgument, "Scan target must be a struct")
+	}
+	m := &rowMapper{columns: columns, targets: make([]columnTarget, len(columns))}
+	if options.Unknown == UnknownColumnsCapture {
+		if !reflect.PointerTo(t).Implements(reflect.TypeOf((*ColumnCapturer)(nil)).Elem()) {
+			return nil, porterr.New(porterr.PortErrorArgument, t.Name()+" must implement ColumnCapturer to capture columns")
+		}
+		m.capture = true
+	}
+	var nested = make(map[string]struct{})
+	for i := range columns {
+		target, ok := resolveColumn(t, columns[i], nil)
+		if !ok {
+			if options.Unknown == UnknownColumnsError {
+				return nil, porterr.New(porterr.PortErrorArgument, "Column "+columns[i]+" is not found in "+t.Name())
+			}
+			target = columnTarget{value: -1, field: -1}
+		}
+		if len(target.path) > 0 {
+			key := fmt.Sprint(target.path)
+			if _, ok = nested[key]; !ok {
+				nested[key] = struct{}{}
+				m.nested = append(m.nested, target.path)
+			}
+		}
+		m.targets[i] = target
+	}
+	// deeper models first to release empty parents
+	sort.SliceStable(m.nested, func(i, j int) bool {
+		return len(m.nested[i]) > len(m.nested[j])
+	})
+	return m, nil
+}
+
+// nestedValue struct value by path. Nil pointers are allocated
+func nestedValue(v reflect.Value, path []int) reflect.Value {
+	for _, i := range path {
+		v = v.Field(i)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+	return v
+}
+
+// scan current row to struct value
+func (m *rowMapper) scan(rows *sql.Rows, v reflect.Value) porterr.IError {
+	dest := m.destinations(v)
+	if err := rows.Scan(dest...); err != nil {
+		return porterr.New(porterr.PortErrorIO, "Rows scan error: "+err.Error())
+	}
+	return m.complete(v, dest)
+}
+
+// destinations scan destinations in struct value
+func (m *rowMapper) destinations(v reflect.Value) []any {
+	dest := make([]any, len(m.columns))
+	for i, target := range m.targets {
+		switch {
+		case target.value >= 0:
+			dest[i] = nestedValue(v, target.path).Addr().Interface().(IModel).Values()[target.value]
+		case target.field >= 0:
+			dest[i] = nestedValue(v, target.path).Field(target.field).Addr().Interface()
+		default:
+			dest[i] = new(any)
+		}
+	}
+	return dest
+}
+
+// complete release empty related models, decrypt models and capture unknown columns
+func (m *rowMapper) complete(v reflect.Value, dest []any) porterr.IError {
+	for _, path := range m.nested {
+		// related model of left join without matched row stays nil
+		field := nestedValue(v, path[:len(path)-1]).Field(path[len(path)-1])
+		if field.Kind() == reflect.Ptr && field.Elem().IsZero() {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		if e := decryptModel(nestedValue(v, path).Addr().Interface().(IModel)); e != nil {
+			return e
+		}
+	}
+	if model, ok := v.Addr().Interface().(IModel); ok {
+		if e := decryptModel(model); e != nil {
+			return e
+		}
+	}
+	if m.capture {
+		capturer := v.Addr().Interface().(ColumnCapturer)
+		for i, target := range m.targets {
+			if target.value < 0 && target.field < 0 {
+				capturer.CaptureColumn(m.columns[i], *(dest[i].(*any)))
+			}
+		}
+	}
+	return nil
+}
+
+// ScanRow scan current row of rows to model
+// rows.Next must be called before
+func ScanRow(rows *sql.Rows, model any, options ...ScanOptions) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return porterr.New(porterr.PortErrorArgument, "Model must be a not nil pointer")
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return porterr.New(porterr.PortErrorIO, "Rows columns error: "+err.Error())
+	}
+	m, e := newRowMapper(v.Elem().Type(), columns, scanOptions(options))
+	if e != nil {
+		return e
+	}
+	if e = m.scan(rows, v.Elem()); e != nil {
+		return e
+	}
+	return nil
+}
+
+// ScanRows scan all rows to models. Rows are closed
+// T is a model or a struct with embedded and related models
+// Columns with prefix are scanned to related models. Example: author__name to Author.Name
+func ScanRows[T any](rows *sql.Rows, options ...ScanOptions) ([]*T, error) {
+	defer func() { _ = rows.Close() }()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, porterr.New(porterr.PortErrorIO, "Rows columns error: "+err.Error())
+	}
+	m, e := newRowMapper(reflect.TypeOf((*T)(nil)).Elem(), columns, scanOptions(options))
+	if e != nil {
+		return nil, e
+	}
+	items := make([]*T, 0)
+	for rows.Next() {
+		item := new(T)
+		if e = m.scan(rows, reflect.ValueOf(item).Elem()); e != nil {
+			return nil, e
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, NewDatabaseError(err)
+	}
+	return items, nil
+}
+
+// QueryModels query and scan rows to models
+// Unknown columns are ignored
+func QueryModels[T any](q godb.Queryer, query string, args ...any) ([]*T, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, NewDatabaseError(err)
+	}
+	return ScanRows[T](rows)
+}
+
+// scanOptions first option or default
+func scanOptions(options []ScanOptions) ScanOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return ScanOptions{}
+}
@@ -0,0 +1,79 @@
+package gomodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+type BookWithAuthor struct {
+	RelationBook
+	Author  *RelationAuthor
+	Rank    *int `db:"col~rank;ign;"`
+	unknown map[string]any
+}
+
+// CaptureColumn capture unknown column
+func (m *BookWithAuthor) CaptureColumn(column string, value any) {
+	if m.unknown == nil {
+		m.unknown = make(map[string]any)
+	}
+	m.unknown[column] = value
+}
+
+func TestRowMapper(t *testing.T) {
+	columns := []string{"id", "title", "rank", "author__id", "author__name", "score"}
+	t.Run("targets", func(t *testing.T) {
+		m, e := newRowMapper(reflect.TypeOf(BookWithAuthor{}), columns, ScanOptions{})
+		if e != nil {
+			t.Fatal(e)
+		}
+		if m.targets[0].value != 0 || m.targets[1].value != 2 || m.targets[2].field != 2 {
+			t.Fatal("wrong book targets")
+		}
+		if len(m.targets[4].path) != 1 || m.targets[4].path[0] != 1 || m.targets[4].value != 1 {
+			t.Fatal("wrong author targets")
+		}
+		if m.targets[5].value != -1 || m.targets[5].field != -1 || len(m.nested) != 1 {
+			t.Fatal("score must be unknown")
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		if _, e := newRowMapper(reflect.TypeOf(BookWithAuthor{}), columns, ScanOptions{Unknown: UnknownColumnsError}); e == nil {
+			t.Fatal("unknown column must be error")
+		}
+		if _, e := newRowMapper(reflect.TypeOf(RelationBook{}), columns, ScanOptions{Unknown: UnknownColumnsCapture}); e == nil {
+			t.Fatal("model must implement ColumnCapturer")
+		}
+		if _, e := newRowMapper(reflect.TypeOf(1), columns, ScanOptions{}); e == nil {
+			t.Fatal("target must be a struct")
+		}
+	})
+	t.Run("complete", func(t *testing.T) {
+		m, e := newRowMapper(reflect.TypeOf(BookWithAuthor{}), columns, ScanOptions{Unknown: UnknownColumnsCapture})
+		if e != nil {
+			t.Fatal(e)
+		}
+		item := &BookWithAuthor{}
+		dest := m.destinations(reflect.ValueOf(item).Elem())
+		id, title := 1, "Book"
+		*(dest[0].(**int)) = &id
+		*(dest[1].(**string)) = &title
+		*(dest[5].(*any)) = 0.5
+		if e = m.complete(reflect.ValueOf(item).Elem(), dest); e != nil {
+			t.Fatal(e)
+		}
+		if *item.Id != 1 || *item.Title != "Book" || item.Author != nil || item.unknown["score"] != 0.5 {
+			t.Fatal("wrong scanned item")
+		}
+		item = &BookWithAuthor{}
+		dest = m.destinations(reflect.ValueOf(item).Elem())
+		name := "Author"
+		*(dest[4].(**string)) = &name
+		if e = m.complete(reflect.ValueOf(item).Elem(), dest); e != nil {
+			t.Fatal(e)
+		}
+		if item.Author == nil || *item.Author.Name != "Author" {
+			t.Fatal("author must be scanned")
+		}
+	})
+}
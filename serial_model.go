@@ -32,7 +32,7 @@ func BigSerialTable(name string) *gosql.Table {
 
 // TimestampModifier create timestamps
 func TimestampModifier(tb *gosql.Table) {
-	tb.AddColumn("created_at").Type("TIMESTAMP WITH TIME ZONE").Constraint().NotNull().Default("localtimestamp")
+	tb.AddColumn("created_at").Type("TIMESTAMP WITH TIME ZONE").Constraint().NotNull().Default("CURRENT_TIMESTAMP")
 	tb.AddColumn("updated_at").Type("TIMESTAMP WITH TIME ZONE")
 }
 
@@ -0,0 +1,125 @@
+package gomodel
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/dimonrus/porterr"
+)
+
+// key for tenant in context
+type tenantKey struct{}
+
+// WithTenant put tenant identifier to context
+func WithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext get tenant identifier from context
+func TenantFromContext(ctx context.Context) (tenant any, ok bool) {
+	if ctx == nil {
+		return
+	}
+	tenant = ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// position of tenant field in model values by model type. -1 if model is not multi-tenant
+var tenantPositions sync.Map
+
+// tenantPosition get position of tenant field in model values
+func tenantPosition(model IModel) int {
+	te := reflect.TypeOf(model)
+	if pos, ok := tenantPositions.Load(te); ok {
+		return pos.(int)
+	}
+	var pos = -1
+	if meta := PrepareMetaModel(model); meta != nil {
+		for i := range meta.Fields {
+			if meta.Fields[i].IsTenant {
+				pos = i
+				break
+			}
+		}
+	}
+	tenantPositions.Store(te, pos)
+	return pos
+}
+
+// IsTenantModel check if model has tenant column
+func IsTenantModel(model IModel) bool {
+	return model != nil && tenantPosition(model) >= 0
+}
+
+// isTenantSet check if tenant value exists for multi-tenant model
+// Returns true for models without tenant column
+func isTenantSet(model IModel) bool {
+	if model == nil {
+		return true
+	}
+	pos := tenantPosition(model)
+	if pos < 0 {
+		return true
+	}
+	ve := reflect.ValueOf(model.Values()[pos]).Elem()
+	if ve.Kind() == reflect.Ptr || ve.Kind() == reflect.Interface {
+		return !ve.IsNil()
+	}
+	return !ve.IsZero()
+}
+
+// errorNoTenant tenant is not present error
+func errorNoTenant(model IModel) porterr.IError {
+	return porterr.New(porterr.PortErrorAccess, "Tenant is not present for model: "+model.Table()).HTTP(http.StatusForbidden)
+}
+
+// applyTenant set tenant from context to model tenant field
+// Returns error if model is multi-tenant and context has no tenant
+// or model already has value of another tenant
+func applyTenant(ctx context.Context, model IModel) porterr.IError {
+	if model == nil {
+		return nil
+	}
+	pos := tenantPosition(model)
+	if pos < 0 {
+		return nil
+	}
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return errorNoTenant(model)
+	}
+	field := reflect.ValueOf(model.Values()[pos]).Elem()
+	target := field.Type()
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	tv := reflect.ValueOf(tenant)
+	if !tv.Type().ConvertibleTo(target) {
+		return porterr.NewF(porterr.PortErrorType, "Tenant type %T is not convertible to %s", tenant, target.String()).HTTP(http.StatusForbidden)
+	}
+	tv = tv.Convert(target)
+	current := field
+	if field.Kind() == reflect.Ptr {
+		if !field.IsNil() {
+			current = field.Elem()
+		} else {
+			current = reflect.Value{}
+		}
+	}
+	if current.IsValid() && !current.IsZero() {
+		if current.Interface() != tv.Interface() {
+			return porterr.New(porterr.PortErrorAccess, "Model belongs to another tenant: "+model.Table()).HTTP(http.StatusForbidden)
+		}
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(target)
+		value.Elem().Set(tv)
+		field.Set(value)
+	} else {
+		field.Set(tv)
+	}
+	return nil
+}
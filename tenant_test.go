@@ -0,0 +1,183 @@
+package gomodel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type TenantModel struct {
+	Id        *int       `json:"id" db:"col~id;prk;req;seq;"`
+	TenantId  *int       `json:"tenantId" db:"col~tenant_id;tnt;req;"`
+	Name      *string    `json:"name" db:"col~name;req;"`
+	SomeInt   *int       `json:"someInt" db:"col~some_int;unq;"`
+	UpdatedAt *time.Time `json:"updatedAt" db:"col~updated_at;uat;"`
+	DeletedAt *time.Time `json:"deletedAt" db:"col~deleted_at;dat;"`
+}
+
+// Model table name
+func (m *TenantModel) Table() string { return "tenant_model" }
+
+// Model columns
+func (m *TenantModel) Columns() []string {
+	return []string{"id", "tenant_id", "name", "some_int", "updated_at", "deleted_at"}
+}
+
+// Model values
+func (m *TenantModel) Values() []any {
+	return []any{&m.Id, &m.TenantId, &m.Name, &m.SomeInt, &m.UpdatedAt, &m.DeletedAt}
+}
+
+type TenantCodeModel struct {
+	Code     *string `json:"code" db:"col~code;prk;req;"`
+	TenantId *int64  `json:"tenantId" db:"col~tenant_id;tnt;req;"`
+	Name     *string `json:"name" db:"col~name;req;"`
+}
+
+// Model table name
+func (m *TenantCodeModel) Table() string { return "tenant_code_model" }
+
+// Model columns
+func (m *TenantCodeModel) Columns() []string {
+	return []string{"code", "tenant_id", "name"}
+}
+
+// Model values
+func (m *TenantCodeModel) Values() []any {
+	return []any{&m.Code, &m.TenantId, &m.Name}
+}
+
+func TestTenantSQL(t *testing.T) {
+	id, name, tenant := 1, "foo", 5
+	t.Run("no_tenant", func(t *testing.T) {
+		m := &TenantModel{Id: &id, Name: &name}
+		if GetLoadSQL(m) != nil || GetUpdateSQL(m) != nil || GetDeleteSQL(m) != nil || GetSaveSQL(m) != nil || GetInsertSQL(m) != nil {
+			t.Fatal("query without tenant must be nil")
+		}
+	})
+	t.Run("load", func(t *testing.T) {
+		m := &TenantModel{Id: &id, TenantId: &tenant}
+		query, params, _ := GetLoadSQL(m).SQL()
+		t.Log(query)
+		if query != "SELECT id, tenant_id, name, some_int, updated_at, deleted_at FROM tenant_model WHERE (id = ? AND deleted_at IS NULL AND tenant_id = ?)" {
+			t.Fatal("wrong load query")
+		}
+		if len(params) != 2 || params[1] != &m.TenantId {
+			t.Fatal("wrong load params")
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		m := &TenantModel{Id: &id, TenantId: &tenant, Name: &name}
+		query, params, _ := GetUpdateSQL(m).SQL()
+		t.Log(query)
+		if query != "UPDATE tenant_model SET name = ?, some_int = ?, updated_at = NOW() WHERE (id = ? AND tenant_id = ?) RETURNING updated_at, deleted_at;" {
+			t.Fatal("wrong update query")
+		}
+		if len(params) != 4 || params[3] != &m.TenantId {
+			t.Fatal("wrong update params")
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		m := &TenantModel{Id: &id, TenantId: &tenant}
+		query, _, _ := GetDeleteSQL(m).SQL()
+		t.Log(query)
+		if query != "UPDATE tenant_model SET updated_at = NOW(), deleted_at = NOW() WHERE (id = ? AND tenant_id = ?) RETURNING updated_at, deleted_at;" {
+			t.Fatal("wrong delete query")
+		}
+	})
+	t.Run("save_insert", func(t *testing.T) {
+		m := &TenantModel{TenantId: &tenant, Name: &name}
+		query, params, _ := GetSaveSQL(m).SQL()
+		t.Log(query)
+		if query != "INSERT INTO tenant_model (name, some_int, tenant_id) VALUES (?, ?, ?) RETURNING id, updated_at, deleted_at;" {
+			t.Fatal("wrong save insert query")
+		}
+		if len(params) != 3 || params[2] != &m.TenantId {
+			t.Fatal("wrong save insert params")
+		}
+	})
+	t.Run("save_upsert", func(t *testing.T) {
+		code, tenantId := "foo", int64(5)
+		m := &TenantCodeModel{Code: &code, TenantId: &tenantId, Name: &name}
+		query, _, _ := GetSaveSQL(m).SQL()
+		t.Log(query)
+		if query != "INSERT INTO tenant_code_model (code, name, tenant_id) VALUES (?, ?, ?) ON CONFLICT (code) DO UPDATE SET name = ? WHERE (tenant_code_model.tenant_id = EXCLUDED.tenant_id);" {
+			t.Fatal("wrong save upsert query")
+		}
+	})
+	t.Run("insert_fields", func(t *testing.T) {
+		m := &TenantModel{TenantId: &tenant, Name: &name}
+		query, params, _ := GetInsertSQL(m, &m.Name).SQL()
+		t.Log(query)
+		if query != "INSERT INTO tenant_model (name, tenant_id) VALUES (?, ?);" {
+			t.Fatal("wrong insert query")
+		}
+		if len(params) != 2 || params[1] != &m.TenantId {
+			t.Fatal("wrong insert params")
+		}
+	})
+	t.Run("insert_conflict", func(t *testing.T) {
+		code, tenantId := "bar", int64(5)
+		m := &TenantCodeModel{Code: &code, TenantId: &tenantId, Name: &name}
+		query, _, _ := GetInsertSQL(m, &m.Code, &m.Name).SQL()
+		t.Log(query)
+		if query != "INSERT INTO tenant_code_model (code, name, tenant_id) VALUES (?, ?, ?) ON CONFLICT (code) DO UPDATE SET name = ? WHERE (tenant_code_model.tenant_id = EXCLUDED.tenant_id);" {
+			t.Fatal("wrong insert conflict query")
+		}
+	})
+}
+
+func TestApplyTenant(t *testing.T) {
+	t.Run("no_tenant", func(t *testing.T) {
+		if e := applyTenant(context.Background(), &TenantModel{}); e == nil {
+			t.Fatal("must be an error")
+		}
+		if e := applyTenant(context.Background(), &InsertModel1{}); e != nil {
+			t.Fatal("model without tenant column must be ignored")
+		}
+	})
+	t.Run("convert", func(t *testing.T) {
+		m := &TenantCodeModel{}
+		if e := applyTenant(WithTenant(context.Background(), 7), m); e != nil {
+			t.Fatal(e)
+		}
+		if m.TenantId == nil || *m.TenantId != 7 {
+			t.Fatal("wrong tenant value")
+		}
+		if e := applyTenant(WithTenant(context.Background(), "7"), &TenantCodeModel{}); e == nil {
+			t.Fatal("must be a type error")
+		}
+	})
+	t.Run("another_tenant", func(t *testing.T) {
+		tenant := 8
+		m := &TenantModel{TenantId: &tenant}
+		if e := applyTenant(WithTenant(context.Background(), 8), m); e != nil {
+			t.Fatal(e)
+		}
+		if e := applyTenant(WithTenant(context.Background(), 9), m); e == nil {
+			t.Fatal("must be an error")
+		}
+	})
+	t.Run("load", func(t *testing.T) {
+		if e := LoadContext(context.Background(), nil, &TenantModel{}); e == nil {
+			t.Fatal("must be an error")
+		}
+	})
+}
+
+func TestNewCollectionContext(t *testing.T) {
+	if NewCollectionContext[TenantModel](context.Background()) != nil {
+		t.Fatal("collection without tenant must be nil")
+	}
+	if e := NewCollection[TenantModel]().Load(nil); e == nil {
+		t.Fatal("not scoped collection must not be loaded")
+	}
+	c := NewCollectionContext[TenantModel](WithTenant(context.Background(), 5))
+	t.Log(c.String())
+	if c.String() != "SELECT id, tenant_id, name, some_int, updated_at, deleted_at FROM tenant_model WHERE (tenant_model.tenant_id = ?)" {
+		t.Fatal("wrong collection query")
+	}
+	if len(c.GetArguments()) != 1 || c.GetArguments()[0] != 5 {
+		t.Fatal("wrong collection arguments")
+	}
+}
@@ -0,0 +1,197 @@
+package gomodel
+
+import (
+	"database/sql"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+)
+
+const (
+	// TxDefaultRetries default count of closure retries
+	TxDefaultRetries = 3
+	// TxDefaultBackoff default delay before first retry
+	TxDefaultBackoff = time.Millisecond * 20
+	// TxDefaultMaxBackoff default max delay between retries
+	TxDefaultMaxBackoff = time.Second
+)
+
+// TxRetryableErrors names of postgres errors when whole transaction can be retried
+var TxRetryableErrors = []string{"serialization_failure", "deadlock_detected"}
+
+// TxOptions transaction options
+type TxOptions struct {
+	// Isolation level. sql.LevelDefault means database default
+	Isolation sql.IsolationLevel
+	// ReadOnly transaction mode
+	ReadOnly bool
+	// Retries max count of retries on serialization failures and deadlocks. Negative value disable retries
+	Retries int
+	// Backoff delay before first retry. Doubled on each next retry
+	Backoff time.Duration
+	// MaxBackoff max delay between retries
+	MaxBackoff time.Duration
+}
+
+// mode prepare SET TRANSACTION query
+func (o TxOptions) mode() (query string, e porterr.IError) {
+	var modes []string
+	switch o.Isolation {
+	case sql.LevelDefault:
+	case sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+		modes = append(modes, "ISOLATION LEVEL "+strings.ToUpper(o.Isolation.String()))
+	default:
+		e = porterr.New(porterr.PortErrorArgument, "Isolation level is not supported: "+o.Isolation.String())
+		return
+	}
+	if o.ReadOnly {
+		modes = append(modes, "READ ONLY")
+	}
+	if len(modes) > 0 {
+		query = "SET TRANSACTION " + strings.Join(modes, " ") + ";"
+	}
+	return
+}
+
+// delay before retry
+// attempt - number of retry starting from 0
+func (o TxOptions) delay(attempt int) time.Duration {
+	backoff, maxBackoff := o.Backoff, o.MaxBackoff
+	if backoff <= 0 {
+		backoff = TxDefaultBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = TxDefaultMaxBackoff
+	}
+	for i := 0; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	// jitter up to half of delay
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// retries count of retries
+func (o TxOptions) retries() int {
+	if o.Retries == 0 {
+		return TxDefaultRetries
+	}
+	if o.Retries < 0 {
+		return 0
+	}
+	return o.Retries
+}
+
+// Tx transaction queryer passed to InTx closure
+type Tx struct {
+	*godb.SqlTx
+	// nesting level. 0 for root transaction
+	depth int
+}
+
+// Depth level of nested transaction
+func (tx *Tx) Depth() int {
+	return tx.depth
+}
+
+// IsRetryable check if error is a serialization failure or a deadlock
+func IsRetryable(e porterr.IError) bool {
+	if e == nil || e.Origin() == nil {
+		return false
+	}
+	for _, name := range TxRetryableErrors {
+		if e.Origin().Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// InTx run closure in transaction
+// If q is *godb.DBO new transaction is started, committed on success and rolled back on error.
+// Whole closure is retried on serialization failures and deadlocks with backoff.
+// If q is a transaction, closure runs inside SAVEPOINT. Options are ignored for nested calls.
+// If q is a *Router, transaction is started on primary
+func InTx(q godb.Queryer, fn func(tx godb.Queryer) porterr.IError, opts TxOptions) (e porterr.IError) {
+	switch db := q.(type) {
+	case *godb.DBO:
+		for attempt := 0; ; attempt++ {
+			e = inTx(db, fn, opts)
+			if !IsRetryable(e) || attempt >= opts.retries() {
+				return
+			}
+			time.Sleep(opts.delay(attempt))
+		}
+	case *Tx:
+		return inSavepoint(&Tx{SqlTx: db.SqlTx, depth: db.depth + 1}, fn)
+	case *godb.SqlTx:
+		return inSavepoint(&Tx{SqlTx: db, depth: 1}, fn)
+	case *Router:
+		return InTx(db.Primary(), fn, opts)
+	}
+	return porterr.New(porterr.PortErrorArgument, "Queryer must be *godb.DBO or transaction")
+}
+
+// inTx run closure in new transaction
+func inTx(db *godb.DBO, fn func(tx godb.Queryer) porterr.IError, opts TxOptions) (e porterr.IError) {
+	mode, e := opts.mode()
+	if e != nil {
+		return
+	}
+	stx, err := db.Begin()
+	if err != nil {
+		return porterr.New(porterr.PortErrorTransaction, "Can't begin transaction: "+err.Error())
+	}
+	tx := &Tx{SqlTx: stx}
+	defer pinClock(stx)()
+	var done bool
+	defer func() {
+		if !done {
+			_ = tx.Rollback()
+		}
+	}()
+	if mode != "" {
+		if _, err = tx.Exec(mode); err != nil {
+			return NewDatabaseError(err)
+		}
+	}
+	e = fn(tx)
+	if e != nil {
+		return
+	}
+	done = true
+	if err = tx.Commit(); err != nil {
+		return NewDatabaseError(err)
+	}
+	return
+}
+
+// inSavepoint run closure inside savepoint
+func inSavepoint(tx *Tx, fn func(tx godb.Queryer) porterr.IError) (e porterr.IError) {
+	name := "gomodel_sp_" + strconv.Itoa(tx.depth)
+	if _, err := tx.Exec("SAVEPOINT " + name + ";"); err != nil {
+		return NewDatabaseError(err)
+	}
+	defer pinClock(tx.SqlTx)()
+	var done bool
+	defer func() {
+		if !done {
+			_, _ = tx.Exec("ROLLBACK TO SAVEPOINT " + name + ";")
+		}
+	}()
+	e = fn(tx)
+	if e != nil {
+		return
+	}
+	done = true
+	if _, err := tx.Exec("RELEASE SAVEPOINT " + name + ";"); err != nil {
+		return NewDatabaseError(err)
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package gomodel
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimonrus/godb/v2"
+	"github.com/dimonrus/porterr"
+	"github.com/lib/pq"
+)
+
+func TestTxOptions(t *testing.T) {
+	t.Run("mode", func(t *testing.T) {
+		query, e := TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}.mode()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if query != "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE READ ONLY;" {
+			t.Fatal("wrong mode query: " + query)
+		}
+		query, e = TxOptions{Isolation: sql.LevelRepeatableRead}.mode()
+		if query != "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ;" {
+			t.Fatal("wrong mode query: " + query)
+		}
+		query, e = TxOptions{}.mode()
+		if query != "" || e != nil {
+			t.Fatal("default must be empty")
+		}
+		_, e = TxOptions{Isolation: sql.LevelLinearizable}.mode()
+		if e == nil {
+			t.Fatal("linearizable must be an error")
+		}
+	})
+	t.Run("delay", func(t *testing.T) {
+		opts := TxOptions{Backoff: time.Millisecond * 10, MaxBackoff: time.Millisecond * 30}
+		for attempt, max := range []time.Duration{10, 20, 30, 30} {
+			d := opts.delay(attempt)
+			if d < max*time.Millisecond/2 || d > max*time.Millisecond {
+				t.Fatalf("wrong delay %v for attempt %v", d, attempt)
+			}
+		}
+	})
+	t.Run("retries", func(t *testing.T) {
+		if (TxOptions{}).retries() != TxDefaultRetries {
+			t.Fatal("wrong default retries")
+		}
+		if (TxOptions{Retries: -1}).retries() != 0 {
+			t.Fatal("retries must be disabled")
+		}
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(NewDatabaseError(&pq.Error{Code: "40001", Message: "could not serialize access"})) {
+		t.Fatal("serialization failure must be retryable")
+	}
+	if !IsRetryable(NewDatabaseError(&pq.Error{Code: "40P01", Message: "deadlock detected"})) {
+		t.Fatal("deadlock must be retryable")
+	}
+	if IsRetryable(NewDatabaseError(&pq.Error{Code: "23505", Message: "duplicate key"})) {
+		t.Fatal("unique violation must not be retryable")
+	}
+	if IsRetryable(NewDatabaseError(errors.New("some error"))) {
+		t.Fatal("std error must not be retryable")
+	}
+	if IsRetryable(nil) {
+		t.Fatal("nil must not be retryable")
+	}
+}
+
+func TestInTx(t *testing.T) {
+	e := InTx(NewRouter(&Router{}), func(tx godb.Queryer) porterr.IError { return nil }, TxOptions{})
+	if e == nil {
+		t.Fatal("must be an error for unsupported queryer")
+	}
+	//db, _ := initDb()
+	//e = InTx(db, func(tx godb.Queryer) porterr.IError {
+	//	model := &DictionaryModel{Id: gohelp.Ptr[int32](1001), Type: gohelp.Ptr("tx"), Code: gohelp.Ptr("one")}
+	//	if e := Save(tx, model); e != nil {
+	//		return e
+	//	}
+	//	return InTx(tx, func(tx godb.Queryer) porterr.IError {
+	//		return Delete(tx, model)
+	//	}, TxOptions{})
+	//}, TxOptions{Isolation: sql.LevelSerializable})
+}